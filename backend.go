@@ -0,0 +1,121 @@
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Backend is the subset of node functionality the package relies on.
+// CallContext is used for raw JSON-RPC requests such as eth_call with state overrides,
+// which ethereum.ContractCaller cannot express.
+type Backend interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// EthClientBackend adapts an *ethclient.Client to Backend.
+type EthClientBackend struct {
+	*ethclient.Client
+}
+
+func NewEthClientBackend(client *ethclient.Client) *EthClientBackend {
+	return &EthClientBackend{Client: client}
+}
+
+// Dial connects to rawurl and wraps the resulting client as a Backend.
+func Dial(rawurl string) (*EthClientBackend, error) {
+	client, err := ethclient.Dial(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEthClientBackend(client), nil
+}
+
+func (b *EthClientBackend) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return b.Client.Client().CallContext(ctx, result, method, args...)
+}
+
+// ChainClient is Backend without CallContext, the typed methods clients such
+// as the Client of go-ethereum's simulated backend implement.
+type ChainClient interface {
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// ClientBackend adapts a ChainClient to Backend, sending raw requests through
+// the RPC client it wraps.
+type ClientBackend struct {
+	ChainClient
+	rpc *rpc.Client
+}
+
+// NewClientBackend wraps client as a Backend. client must expose its RPC client
+// with a Client() *rpc.Client method, or embed a value that does, like the
+// simulated backend's Client embeds an *ethclient.Client.
+func NewClientBackend(client ChainClient) (*ClientBackend, error) {
+	rpcClient := rpcClientOf(client)
+	if rpcClient == nil {
+		return nil, fmt.Errorf("client %T does not expose its RPC client", client)
+	}
+
+	return &ClientBackend{ChainClient: client, rpc: rpcClient}, nil
+}
+
+func (b *ClientBackend) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return b.rpc.CallContext(ctx, result, method, args...)
+}
+
+type rpcClienter interface {
+	Client() *rpc.Client
+}
+
+// rpcClientOf returns the RPC client of client, or of the first value it embeds
+// exposing one, nil when there is none.
+func rpcClientOf(client any) *rpc.Client {
+	if c, ok := client.(rpcClienter); ok {
+		return c.Client()
+	}
+
+	// the embedded field's name shadows the promoted Client method
+	value := reflect.Indirect(reflect.ValueOf(client))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.Anonymous || !field.IsExported() {
+			continue
+		}
+		embedded := value.Field(i)
+		if embedded.Kind() == reflect.Pointer && embedded.IsNil() {
+			continue
+		}
+		if c, ok := embedded.Interface().(rpcClienter); ok {
+			return c.Client()
+		}
+	}
+
+	return nil
+}
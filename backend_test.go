@@ -0,0 +1,176 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+var errNotImplemented = errors.New("not implemented by fake backend")
+
+// fakeBackend is an in-memory Backend whose behaviour is set per test.
+type fakeBackend struct {
+	callContext func(ctx context.Context, result interface{}, method string, args ...interface{}) error
+	code        map[common.Address][]byte
+	blockNumber uint64
+	chainID     *big.Int
+
+	// gasPrice, gas and nonce enable sending transactions: SuggestGasPrice,
+	// EstimateGas and SendTransaction fail while gasPrice is nil. Sent
+	// transactions are recorded and mined successfully at blockNumber.
+	gasPrice  *big.Int
+	gas       uint64
+	nonce     uint64
+	estimated []ethereum.CallMsg
+	sent      []*types.Transaction
+
+	// mined reports whether a sent transaction has a receipt, all of them have
+	// one when nil.
+	mined func(tx *types.Transaction) bool
+
+	// feeHistory answers eth_feeHistory, which fails while it is nil so that
+	// transactions are priced as legacy ones.
+	feeHistory *feeHistory
+}
+
+var _ Backend = (*fakeBackend)(nil)
+
+func (f *fakeBackend) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if method == "eth_feeHistory" {
+		if f.feeHistory == nil {
+			return errNotImplemented
+		}
+		*result.(*feeHistory) = *f.feeHistory
+		return nil
+	}
+	if f.callContext == nil {
+		return errNotImplemented
+	}
+	return f.callContext(ctx, result, method, args...)
+}
+
+func (f *fakeBackend) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return f.code[account], nil
+}
+
+func (f *fakeBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	return f.blockNumber, nil
+}
+
+func (f *fakeBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	if f.chainID == nil {
+		return big.NewInt(1), nil
+	}
+	return f.chainID, nil
+}
+
+func (f *fakeBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	if f.gasPrice == nil {
+		return nil, errNotImplemented
+	}
+	return f.gasPrice, nil
+}
+
+func (f *fakeBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	if f.gasPrice == nil {
+		return 0, errNotImplemented
+	}
+	f.estimated = append(f.estimated, call)
+	return f.gas, nil
+}
+
+func (f *fakeBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	if f.gasPrice == nil {
+		return 0, errNotImplemented
+	}
+	return f.nonce, nil
+}
+
+func (f *fakeBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if f.gasPrice == nil {
+		return errNotImplemented
+	}
+	f.sent = append(f.sent, tx)
+	return nil
+}
+
+func (f *fakeBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	for _, tx := range f.sent {
+		if tx.Hash() == txHash && (f.mined == nil || f.mined(tx)) {
+			return &types.Receipt{
+				Type:        tx.Type(),
+				Status:      types.ReceiptStatusSuccessful,
+				TxHash:      txHash,
+				BlockNumber: new(big.Int).SetUint64(f.blockNumber),
+			}, nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func TestNewMultiCall_Backend(t *testing.T) {
+	t.Run("deployed when code is present", func(t *testing.T) {
+		registerCode(t, []byte{0x60, 0x80}, "v1")
+		backend := &fakeBackend{code: map[common.Address][]byte{OMNES_MULTICALL_ADDRESS: {0x60, 0x80}}}
+
+		m, err := NewMultiCall(backend, nil)
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+		if !m.IsDeployed() || *m.ContractAddress != OMNES_MULTICALL_ADDRESS {
+			t.Fatalf("ContractAddress = %v, want %s", m.ContractAddress, OMNES_MULTICALL_ADDRESS)
+		}
+	})
+
+	t.Run("deployless when code is missing", func(t *testing.T) {
+		m, err := NewMultiCall(&fakeBackend{}, nil)
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+		if !m.IsDeployless() {
+			t.Fatalf("ContractAddress = %v, want nil", m.ContractAddress)
+		}
+	})
+}
+
+func TestClientBackend_Simulated(t *testing.T) {
+	sim := simulated.NewBackend(types.GenesisAlloc{})
+	defer sim.Close()
+
+	backend, err := NewClientBackend(sim.Client())
+	if err != nil {
+		t.Fatalf("NewClientBackend: %v", err)
+	}
+	m, err := NewMultiCall(backend, nil)
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+	if !m.IsDeployless() {
+		t.Fatalf("ContractAddress = %v, want deployless calls on the empty chain", m.ContractAddress)
+	}
+
+	// the identity precompile echoes its input
+	identity := common.BytesToAddress([]byte{0x04})
+	input := common.LeftPadBytes(big.NewInt(42).Bytes(), 32)
+	calls := []Call{NewCall(identity, "", nil, input, []string{"uint256"}, nil)}
+
+	result := m.TryAggregateStatic(calls, true, backend, nil, nil, nil)
+	if result.Error != nil {
+		t.Fatalf("Error = %v", result.Error)
+	}
+	if callResults := result.CallResults(); len(callResults) != 1 || callResults[0].Decoded[0].(*big.Int).Int64() != 42 {
+		t.Fatalf("CallResults = %v, want 42 echoed", callResults)
+	}
+
+	t.Run("client without RPC client", func(t *testing.T) {
+		if _, err := NewClientBackend(&fakeBackend{}); err == nil {
+			t.Fatalf("err = nil, want the missing RPC client reported")
+		}
+	})
+}
@@ -5,20 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"time"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // readContract makes a call to a contract and returns the returned bytecode.
 func readContract(
-	client *ethclient.Client, from *common.Address, to *common.Address, value *big.Int, encodedCall []byte, blockNumber *big.Int, overrides StateOverride,
+	ctx context.Context, client Backend, from *common.Address, to *common.Address, value *big.Int, encodedCall []byte, blockNumber *big.Int, overrides StateOverride,
 ) ([]byte, *ethereum.CallMsg, error) {
 	if from == nil {
 		from = &ZERO_ADDRESS
@@ -46,7 +43,7 @@ func readContract(
 	}
 
 	var result hexutil.Bytes
-	err := client.Client().CallContext(context.Background(), &result, "eth_call", call, blockIdentifier, overrides)
+	err := client.CallContext(ctx, &result, "eth_call", call, blockIdentifier, overrides)
 
 	// result, err := client.CallContract(context.Background(),
 	// 	call,
@@ -59,62 +56,113 @@ func readContract(
 	return []byte(result), call.ToEthereumCallMsg(), nil
 }
 
-// createTransaction creates a new transaction object.
+// createTransaction creates a new transaction object for chainId, a dynamic fee
+// transaction priced with fees unless the chain has no base fee. Its nonce is
+// allocated by nonces and must be released if the transaction is not sent.
 func createTransaction(
-	client *ethclient.Client,
+	ctx context.Context,
+	client Backend,
+	chainId *big.Int,
 	from *common.Address,
 	to *common.Address,
 	msgValue *big.Int,
 	callData []byte,
+	fees FeeStrategy,
+	nonces *NonceManager,
 ) (*types.Transaction, error) {
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	feeCap, tipCap, err := suggestFees(ctx, client, fees)
 	if err != nil {
 		return nil, err
 	}
 
-	gasLimit, err := client.EstimateGas(
-		context.Background(),
-		ethereum.CallMsg{
-			From: *from, // the sender of the 'transaction'
-			To:   to,    // the destination contract (nil for contract creation)
-			Gas:  0,     // if 0, the call executes with near-infinite gas
-			// GasPrice:  gasPrice        // wei <-> gas exchange ratio
-			GasFeeCap: gasPrice, // EIP-1559 fee cap per gas.
-			GasTipCap: gasPrice, // EIP-1559 tip per gas.
-			Value:     msgValue,
-			Data:      callData,
-		},
-	)
+	msg := ethereum.CallMsg{
+		From:  *from, // the sender of the 'transaction'
+		To:    to,    // the destination contract (nil for contract creation)
+		Gas:   0,     // if 0, the call executes with near-infinite gas
+		Value: msgValue,
+		Data:  callData,
+	}
+	if tipCap == nil {
+		msg.GasPrice = feeCap
+	} else {
+		msg.GasFeeCap = feeCap
+		msg.GasTipCap = tipCap
+	}
 
+	gasLimit, err := client.EstimateGas(ctx, msg)
 	if err != nil {
 		return nil, err
 	}
 
-	nonce, err := client.PendingNonceAt(context.Background(), *from)
+	// allocated last, once nothing else can fail, for both transaction types
+	nonce, err := nonces.Next(ctx, client, *from)
 	if err != nil {
 		return nil, err
 	}
 
-	return types.NewTransaction(nonce, *to, msgValue, gasLimit, gasPrice, nil), nil
+	if tipCap == nil {
+		return types.NewTransaction(nonce, *to, msgValue, gasLimit, feeCap, callData), nil
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     nonce,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       gasLimit,
+		To:        to,
+		Value:     msgValue,
+		Data:      callData,
+	}), nil
 }
 
-// sendSignedTransaction sends a signed transaction
-func sendSignedTransaction(client *ethclient.Client, tx *types.Transaction) (*types.Receipt, error) {
-	err := client.SendTransaction(context.Background(), tx)
-	if err != nil {
-		return nil, fmt.Errorf("error sending transaction (txHash=%v): %v", tx.Hash(), err)
+// sendSignedTransaction sends a signed transaction and waits for it, or for one
+// of the replacements policy sends signed with sign, to be mined. The mined
+// transaction is returned with its receipt, or the last one sent on failure,
+// and the hashes of every transaction sent. The nonce of a rejected transaction
+// is given back to nonces.
+func sendSignedTransaction(
+	ctx context.Context, client Backend, tx *types.Transaction, policy ReplacementPolicy,
+	sign func(tx *types.Transaction) (*types.Transaction, error), nonces *NonceManager,
+) (*types.Receipt, *types.Transaction, []common.Hash, error) {
+	if err := broadcastTransaction(ctx, client, tx, nonces); err != nil {
+		return nil, tx, nil, err
 	}
 
-	// @note implement retry to bump gas
-	d := time.Now().Add(MINING_WAIT_DURATION)
-	ctx, cancel := context.WithDeadline(context.Background(), d)
-	defer cancel()
-	receipt, err := bind.WaitMined(ctx, client, tx)
+	return waitMined(ctx, client, tx, policy, sign, nil)
+}
+
+// broadcastTransaction sends a signed transaction, giving its nonce back to
+// nonces when it is rejected.
+func broadcastTransaction(ctx context.Context, client Backend, tx *types.Transaction, nonces *NonceManager) error {
+	err := client.SendTransaction(ctx, tx)
 	if err != nil {
-		return nil, fmt.Errorf("error while waiting for receipt (txHash=%v): %v", tx.Hash(), err)
+		if from, senderErr := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); senderErr == nil {
+			nonces.broadcastFailed(from, err, txNonces(tx, from)...)
+		}
+		return fmt.Errorf("error sending transaction (txHash=%v): %w", tx.Hash(), err)
 	}
 
-	return receipt, nil
+	return nil
+}
+
+// waitMined waits for tx, already sent, or one of its replacements to be mined.
+func waitMined(
+	ctx context.Context, client Backend, tx *types.Transaction, policy ReplacementPolicy,
+	sign func(tx *types.Transaction) (*types.Transaction, error), replaced func(tx *types.Transaction),
+) (*types.Receipt, *types.Transaction, []common.Hash, error) {
+	// MINING_WAIT_DURATION only bounds the wait further, a shorter deadline on ctx still wins.
+	waitCtx, cancel := context.WithTimeout(ctx, MINING_WAIT_DURATION)
+	defer cancel()
+
+	return waitReplacing(waitCtx, client, tx, policy, sign, replaced)
+}
+
+// signWith signs transactions for chainId with signer.
+func signWith(signer SignerInterface, chainId *big.Int) func(tx *types.Transaction) (*types.Transaction, error) {
+	return func(tx *types.Transaction) (*types.Transaction, error) {
+		return signer.SignTx(tx, chainId)
+	}
 }
 
 func parseRevertData(err error) ([]byte, bool) {
@@ -127,7 +175,10 @@ func parseRevertData(err error) ([]byte, bool) {
 			return nil, false
 		}
 
-		revertData := hexutil.MustDecode(errorData)
+		revertData, err := hexutil.Decode(errorData)
+		if err != nil {
+			return nil, false
+		}
 
 		return revertData, true
 
@@ -0,0 +1,212 @@
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	// blockProbeAddress receives blockProbeCode through a state override.
+	blockProbeAddress = common.HexToAddress("0x00000000000000000000000000000000b10c57a4")
+	// returns (block.number, blockhash(block.number - 1), block.timestamp, block.basefee)
+	blockProbeCode  = common.FromHex("0x436000526001430340602052426040524860605260806000f3")
+	blockProbeTypes = []string{"uint256", "bytes32", "uint256", "uint256"}
+)
+
+// multicall3BlockGetters are the Multicall3 getters called along with the calls
+// of tryBlockAndAggregate, which only reports the block number and
+// blockhash(block.number), always zero. Multicall2 lacks the last one.
+var multicall3BlockGetters = []string{"getLastBlockHash()", "getCurrentBlockTimestamp()", "getBasefee()"}
+
+// BlockAndAggregateStatic is like TryAggregateStatic but also sets Result.Block
+// from inside the same execution as the calls, so the block number, timestamp
+// and base fee provably match the returned data. The block is read through
+// tryBlockAndAggregate and the block getters of Multicall3 or Multicall2, or
+// with an extra getChainData() call to the Omnes contract, whose parent hash is
+// read from code placed with a state override unless the chain is known not to
+// support them. Deployless calls read the whole block from that code, so the
+// node must support state overrides. Chunking does not apply: the calls always
+// run in one eth_call.
+func (m *MultiCall) BlockAndAggregateStatic(
+	calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	return m.BlockAndAggregateStaticContext(context.Background(), calls, requireSuccess, client, from, blockNumber, overrides)
+}
+
+// BlockAndAggregateStaticContext is like BlockAndAggregateStatic but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) BlockAndAggregateStaticContext(
+	ctx context.Context, calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.resolve(from, blockNumber, overrides)
+
+	strategy, err := m.strategy(OperationStatic, blockNumber)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+
+	switch strategy {
+	case StrategyMultiCall3, StrategyMultiCall2:
+		getters := multicall3BlockGetters
+		if strategy == StrategyMultiCall2 {
+			getters = getters[:2]
+		}
+
+		result := m.multicall3.TryBlockAndAggregateContext(
+			ctx, withStampCalls(calls, *m.multicall3.ContractAddress, getters...), requireSuccess, client, from, blockNumber, true, overrides,
+		)
+		if result.Error != nil {
+			return result
+		}
+		return withBlockStamp(result, len(getters), stampMulticall3)
+
+	case StrategyOmnes:
+		// getChainData() reports blockhash(block.number), always zero, so the parent
+		// hash comes from the probe
+		stamped, stampCalls := withStampCalls(calls, *m.ContractAddress, "getChainData()"), 1
+		if m.capabilities == nil || m.capabilities.StateOverrides {
+			stamped, stampCalls = withStampCalls(stamped, blockProbeAddress, ""), 2
+			overrides = overrides.Merge(StateOverride{blockProbeAddress: {Code: hexutil.Bytes(blockProbeCode)}})
+		}
+
+		result := m.tryAggregateStatic(ctx, stamped, requireSuccess, client, from, blockNumber, overrides)
+		if result.Error != nil {
+			return result
+		}
+		return withBlockStamp(result, stampCalls, stampChainData)
+	}
+
+	if m.capabilities != nil && !m.capabilities.StateOverrides {
+		return Result{
+			Success: false,
+			Error:   fmt.Errorf("deployless block stamps need state overrides, unsupported on chain %v", m.capabilities.ChainID),
+		}
+	}
+
+	overrides = overrides.Merge(StateOverride{blockProbeAddress: {Code: hexutil.Bytes(blockProbeCode)}})
+	result := deploylessTryAggregateStatic(
+		ctx, withStampCalls(calls, blockProbeAddress, ""), requireSuccess, client, from, blockNumber, overrides,
+	)
+	if result.Error != nil {
+		return result
+	}
+	return withBlockStamp(result, 1, stampProbe)
+}
+
+// withStampCalls appends to calls a call of each of funcSignatures on target,
+// an empty signature sending empty call data. Their results are left undecoded.
+func withStampCalls(calls []Call, target common.Address, funcSignatures ...string) Calls {
+	stamped := append(make(Calls, 0, len(calls)+len(funcSignatures)), calls...)
+	for _, funcSignature := range funcSignatures {
+		if funcSignature == "" {
+			stamped = append(stamped, NewCall(target, "", nil, []byte{}, nil, nil))
+		} else {
+			stamped = append(stamped, NewCall(target, funcSignature, nil, nil, nil, nil))
+		}
+	}
+
+	return stamped
+}
+
+// withBlockStamp moves the outcome of the last stampCalls calls of result into
+// result.Block, as read by stamp.
+func withBlockStamp(result Result, stampCalls int, stamp func(block *BlockStamp, stampResults []CallResult) error) Result {
+	callResults := result.CallResults()
+	if len(callResults) < stampCalls {
+		return Result{Success: false, Error: fmt.Errorf("no block stamp result"), TxOrCall: result.TxOrCall}
+	}
+
+	block := result.Block
+	if block == nil {
+		block = &BlockStamp{}
+	}
+	stampResults := callResults[len(callResults)-stampCalls:]
+	for _, stampResult := range stampResults {
+		if !stampResult.Success {
+			return Result{
+				Success:  false,
+				Error:    fmt.Errorf("error reading the block stamp: %w", stampResult.Revert),
+				TxOrCall: result.TxOrCall,
+			}
+		}
+	}
+	if err := stamp(block, stampResults); err != nil {
+		return Result{Success: false, Error: err, TxOrCall: result.TxOrCall}
+	}
+
+	result.Block = block
+	result.Result = callResults[:len(callResults)-stampCalls]
+	result.TxOrCall.BlockNumber = block.Number
+
+	return result
+}
+
+// stampMulticall3 reads the block getters of Multicall3, the block number being
+// set by tryBlockAndAggregate.
+func stampMulticall3(block *BlockStamp, stampResults []CallResult) error {
+	types := []string{"bytes32", "uint256", "uint256"}
+	values := make([]any, len(stampResults))
+	for i, stampResult := range stampResults {
+		decoded, err := safeDecode(types[i:i+1], stampResult.ReturnData)
+		if err != nil {
+			return fmt.Errorf("error decoding %s: %w", multicall3BlockGetters[i], err)
+		}
+		values[i] = decoded[0]
+	}
+
+	block.ParentHash = common.BytesToHash(toBytes(values[0]))
+	block.Timestamp = values[1].(*big.Int).Uint64()
+	if len(values) > 2 {
+		block.BaseFee = values[2].(*big.Int)
+	}
+
+	return nil
+}
+
+// stampChainData reads the outcome of getChainData() on the Omnes contract and,
+// when it was called, of blockProbeCode for the parent hash. An empty probe
+// result, from a node ignoring state overrides, leaves the parent hash unset.
+func stampChainData(block *BlockStamp, stampResults []CallResult) error {
+	decoded, err := safeDecode(chainDataTypes, stampResults[0].ReturnData)
+	if err != nil {
+		return fmt.Errorf("error decoding getChainData(): %w", err)
+	}
+
+	block.Number = decoded[1].(*big.Int)
+	block.BaseFee = decoded[3].(*big.Int)
+	block.Timestamp = decoded[5].(*big.Int).Uint64()
+
+	if len(stampResults) < 2 || len(stampResults[1].ReturnData) == 0 {
+		return nil
+	}
+	probed, err := safeDecode(blockProbeTypes, stampResults[1].ReturnData)
+	if err != nil {
+		return fmt.Errorf("error decoding the block probe: %w", err)
+	}
+	block.ParentHash = common.BytesToHash(toBytes(probed[1]))
+
+	return nil
+}
+
+// stampProbe reads the outcome of blockProbeCode.
+func stampProbe(block *BlockStamp, stampResults []CallResult) error {
+	if len(stampResults[0].ReturnData) == 0 {
+		return fmt.Errorf("block probe returned no data, the node may not support state overrides")
+	}
+
+	decoded, err := safeDecode(blockProbeTypes, stampResults[0].ReturnData)
+	if err != nil {
+		return fmt.Errorf("error decoding the block probe: %w", err)
+	}
+
+	block.Number = decoded[0].(*big.Int)
+	block.ParentHash = common.BytesToHash(toBytes(decoded[1]))
+	block.Timestamp = decoded[2].(*big.Int).Uint64()
+	block.BaseFee = decoded[3].(*big.Int)
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/omnes-tech/abi"
+)
+
+// stampBackend answers every eth_call with the output built by respond from the
+// sent call and state overrides.
+func stampBackend(respond func(call CallArgs, overrides StateOverride) []byte) *fakeBackend {
+	return &fakeBackend{
+		blockNumber: 50,
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			overrides, _ := args[2].(StateOverride)
+			output := respond(args[0].(CallArgs), overrides)
+			switch r := result.(type) {
+			case *string:
+				*r = hexutil.Encode(output)
+			case *hexutil.Bytes:
+				*r = output
+			}
+			return nil
+		},
+	}
+}
+
+func TestBlockAndAggregateStatic(t *testing.T) {
+	okData, _ := abi.Encode([]string{"uint256"}, big.NewInt(7))
+	parent := common.HexToHash("0xabcdef")
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCalls([]common.Address{target}, []string{"a()"}, nil, nil, [][]string{{"uint256"}}, nil)
+
+	checkCalls := func(t *testing.T, result Result) {
+		t.Helper()
+		if callResults := result.CallResults(); len(callResults) != 1 || callResults[0].Decoded[0].(*big.Int).Int64() != 7 {
+			t.Fatalf("CallResults = %v, want the single user call", callResults)
+		}
+		if result.TxOrCall.BlockNumber.Int64() != 42 {
+			t.Fatalf("BlockNumber = %v, want 42", result.TxOrCall.BlockNumber)
+		}
+	}
+
+	t.Run("omnes contract reads its chain data", func(t *testing.T) {
+		chainData, _ := abi.Encode(
+			chainDataTypes,
+			big.NewInt(1), big.NewInt(42), make([]byte, 32), big.NewInt(9), &ZERO_ADDRESS,
+			big.NewInt(1700000000), big.NewInt(0), big.NewInt(30000000), big.NewInt(0),
+		)
+		probeData, _ := abi.Encode(blockProbeTypes, big.NewInt(42), parent.Bytes(), big.NewInt(1700000000), big.NewInt(9))
+		var sent CallArgs
+		var sentOverrides StateOverride
+		var probeEntry []any
+		backend := stampBackend(func(call CallArgs, overrides StateOverride) []byte {
+			sent, sentOverrides = call, overrides
+			entries := []any{[]any{true, okData}, []any{true, chainData}}
+			if probeEntry != nil {
+				entries = append(entries, probeEntry)
+			}
+			output, _ := abi.Encode([]string{"(bool,bytes)[]"}, entries)
+			return output
+		})
+		m, err := NewMultiCall(backend, nil, WithDeployedMode())
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		probeEntry = []any{true, probeData}
+		result := m.BlockAndAggregateStatic(calls, true, backend, nil, nil, nil)
+		if result.Error != nil {
+			t.Fatalf("Error = %v", result.Error)
+		}
+
+		if !bytes.Contains(sent.Data, abi.EncodeSignature("getChainData()")) {
+			t.Fatalf("getChainData() was not called along with the calls")
+		}
+		if !bytes.Equal(sentOverrides[blockProbeAddress].Code, blockProbeCode) {
+			t.Fatalf("probe code was not overridden: %v", sentOverrides)
+		}
+		checkCalls(t, result)
+		if block := result.Block; block.Number.Int64() != 42 || block.ParentHash != parent ||
+			block.Timestamp != 1700000000 || block.BaseFee.Int64() != 9 {
+			t.Fatalf("Block = %+v", block)
+		}
+
+		probeEntry = []any{true, []byte{}}
+		result = m.BlockAndAggregateStatic(calls, true, backend, nil, nil, nil)
+		if result.Error != nil || result.Block.ParentHash != (common.Hash{}) || result.Block.Number.Int64() != 42 {
+			t.Fatalf("Result = %+v, want the parent hash left unset by an ignored override", result)
+		}
+
+		t.Run("without state overrides", func(t *testing.T) {
+			m.capabilities = &Capabilities{ChainID: big.NewInt(1), Omnes: true, Deployless: true}
+			defer func() { m.capabilities = nil }()
+
+			probeEntry = nil
+			result := m.BlockAndAggregateStatic(calls, true, backend, nil, nil, nil)
+			if result.Error != nil {
+				t.Fatalf("Error = %v", result.Error)
+			}
+			if _, ok := sentOverrides[blockProbeAddress]; ok {
+				t.Fatalf("probe code was overridden on a chain without state overrides")
+			}
+			checkCalls(t, result)
+		})
+	})
+
+	t.Run("multicall3 block and aggregate", func(t *testing.T) {
+		word := func(v int64) []byte { return common.LeftPadBytes(big.NewInt(v).Bytes(), 32) }
+		output, _ := abi.Encode(
+			[]string{"uint256", "bytes32", "(bool,bytes)[]"},
+			big.NewInt(42), make([]byte, 32),
+			[]any{[]any{true, okData}, []any{true, parent.Bytes()}, []any{true, word(1700000000)}, []any{true, word(9)}},
+		)
+		backend, sent := multicall3OnlyBackend(t, output)
+		m, err := NewMultiCall(backend, nil, WithDetector(NewDetector()))
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		result := m.BlockAndAggregateStatic(calls, true, backend, nil, nil, nil)
+		if result.Error != nil {
+			t.Fatalf("Error = %v", result.Error)
+		}
+
+		last := (*sent)[len(*sent)-1]
+		if !bytes.HasPrefix(last.Data, abi.EncodeSignature("tryBlockAndAggregate(bool,(address,bytes)[])")) {
+			t.Fatalf("call data %x, want tryBlockAndAggregate", last.Data)
+		}
+		checkCalls(t, result)
+		if block := result.Block; block.Number.Int64() != 42 || block.ParentHash != parent ||
+			block.Timestamp != 1700000000 || block.BaseFee.Int64() != 9 {
+			t.Fatalf("Block = %+v", block)
+		}
+	})
+
+	t.Run("deployless probe", func(t *testing.T) {
+		probeData, _ := abi.Encode(blockProbeTypes, big.NewInt(42), parent.Bytes(), big.NewInt(1700000000), big.NewInt(9))
+		var probeEntry []any
+		var sentOverrides StateOverride
+		backend := stampBackend(func(call CallArgs, overrides StateOverride) []byte {
+			sentOverrides = overrides
+			output, _ := abi.Encode([]string{"(bool,bytes)[]"}, []any{[]any{true, okData}, probeEntry})
+			return output
+		})
+		m, err := NewMultiCall(backend, nil, WithDeploylessMode())
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		probeEntry = []any{true, probeData}
+		result := m.BlockAndAggregateStatic(calls, true, backend, nil, nil, nil)
+		if result.Error != nil {
+			t.Fatalf("Error = %v", result.Error)
+		}
+		if !bytes.Equal(sentOverrides[blockProbeAddress].Code, blockProbeCode) {
+			t.Fatalf("probe code was not overridden: %v", sentOverrides)
+		}
+		checkCalls(t, result)
+		if block := result.Block; block.Number.Int64() != 42 || block.ParentHash != parent ||
+			block.Timestamp != 1700000000 || block.BaseFee.Int64() != 9 {
+			t.Fatalf("Block = %+v", block)
+		}
+
+		probeEntry = []any{true, []byte{}}
+		result = m.BlockAndAggregateStatic(calls, true, backend, nil, nil, nil)
+		if result.Error == nil || !strings.Contains(result.Error.Error(), "may not support state overrides") {
+			t.Fatalf("Error = %v, want the empty probe to be reported", result.Error)
+		}
+	})
+
+	t.Run("deployless without state overrides", func(t *testing.T) {
+		backend := &fakeBackend{}
+		m := &MultiCall{capabilities: &Capabilities{ChainID: big.NewInt(1), Deployless: true}}
+
+		result := m.BlockAndAggregateStatic(calls, true, backend, nil, nil, nil)
+		if result.Error == nil || !strings.Contains(result.Error.Error(), "need state overrides") {
+			t.Fatalf("Error = %v, want state overrides to be required", result.Error)
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package multicall
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/omnes-tech/abi"
+)
+
+// CallBuilder assembles a batch of calls one at a time:
+//
+//	b := multicall.NewCallBuilder()
+//	b.Add(token, "balanceOf(address)", &holder).Returns("uint256").Label("balance")
+//	b.Add(token, "decimals()").Returns("uint8").AllowFailure()
+//	calls, err := b.CallsWithFailure()
+//
+// Signatures and arguments are checked as calls are added. Returns, Value,
+// AllowFailure and Label apply to the last added call. The first error is kept
+// and reported by Err, Calls and CallsWithFailure.
+type CallBuilder struct {
+	calls  CallsWithFailure
+	labels map[string]int
+	err    error
+}
+
+func NewCallBuilder() *CallBuilder {
+	return &CallBuilder{labels: make(map[string]int)}
+}
+
+// Add appends a call to funcSignature on target. Arguments follow the abi
+// package conventions, e.g. *common.Address for addresses and *big.Int for integers.
+func (b *CallBuilder) Add(target common.Address, funcSignature string, args ...any) *CallBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	index := len(b.calls)
+	types, err := abi.GetSigTypes(funcSignature)
+	if err != nil {
+		b.err = fmt.Errorf("call %d: invalid signature %q: %w", index, funcSignature, err)
+		return b
+	}
+	if len(types) != len(args) {
+		b.err = fmt.Errorf("call %d: %s takes %d arguments, got %d", index, funcSignature, len(types), len(args))
+		return b
+	}
+	if _, err := abi.EncodeWithSignature(funcSignature, args...); err != nil {
+		b.err = fmt.Errorf("call %d: error encoding %s: %w", index, funcSignature, err)
+		return b
+	}
+
+	if len(args) == 0 {
+		args = nil
+	}
+	b.calls = append(b.calls, NewCallWithFailure(target, funcSignature, args, nil, nil, nil, true))
+	return b
+}
+
+// AddCallData appends a call sending already encoded callData to target.
+func (b *CallBuilder) AddCallData(target common.Address, callData []byte) *CallBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	call := NewCallWithFailure(target, "", nil, nil, nil, nil, true)
+	call.CallData = callData
+	b.calls = append(b.calls, call)
+	return b
+}
+
+// Returns sets the types the last call's return data is decoded with.
+func (b *CallBuilder) Returns(returnTypes ...string) *CallBuilder {
+	call := b.last("Returns")
+	if call == nil {
+		return b
+	}
+
+	for _, returnType := range returnTypes {
+		if err := validateType(returnType); err != nil {
+			b.err = fmt.Errorf("call %d: invalid return type %q: %w", len(b.calls)-1, returnType, err)
+			return b
+		}
+	}
+	call.ReturnTypes = returnTypes
+	return b
+}
+
+// Value sets the wei sent along with the last call.
+func (b *CallBuilder) Value(value *big.Int) *CallBuilder {
+	if call := b.last("Value"); call != nil {
+		call.Value = value
+	}
+	return b
+}
+
+// AllowFailure lets the last call revert without failing the batch.
+func (b *CallBuilder) AllowFailure() *CallBuilder {
+	if call := b.last("AllowFailure"); call != nil {
+		call.RequireSuccess = false
+	}
+	return b
+}
+
+// Label names the last call, so its index can be found with IndexOf. The
+// label is also set on the call's CallResult.
+func (b *CallBuilder) Label(label string) *CallBuilder {
+	call := b.last("Label")
+	if call == nil {
+		return b
+	}
+
+	if index, ok := b.labels[label]; ok {
+		b.err = fmt.Errorf("call %d: label %q already used by call %d", len(b.calls)-1, label, index)
+		return b
+	}
+	b.labels[label] = len(b.calls) - 1
+	call.Label = label
+	return b
+}
+
+// IndexOf returns the index of the call labeled label.
+func (b *CallBuilder) IndexOf(label string) (int, bool) {
+	index, ok := b.labels[label]
+	return index, ok
+}
+
+func (b *CallBuilder) Len() int {
+	return len(b.calls)
+}
+
+func (b *CallBuilder) Err() error {
+	return b.err
+}
+
+// Calls returns the built calls for the methods taking []Call.
+func (b *CallBuilder) Calls() (Calls, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return b.calls.toCalls(), nil
+}
+
+// CallsWithFailure returns the built calls for the methods taking []CallWithFailure.
+func (b *CallBuilder) CallsWithFailure() (CallsWithFailure, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return append(CallsWithFailure{}, b.calls...), nil
+}
+
+func (b *CallBuilder) last(method string) *CallWithFailure {
+	if b.err != nil {
+		return nil
+	}
+	if len(b.calls) == 0 {
+		b.err = fmt.Errorf("%s called before any call was added", method)
+		return nil
+	}
+
+	return &b.calls[len(b.calls)-1]
+}
+
+// validateType checks typeStr is an ABI type: an elementary type, a tuple of
+// types, or an array of either.
+func validateType(typeStr string) error {
+	if isArray, _, err := abi.IsArray(typeStr); err != nil {
+		return err
+	} else if isArray {
+		open := strings.LastIndex(typeStr, "[")
+		if size := typeStr[open+1 : len(typeStr)-1]; size != "" {
+			if n, err := strconv.Atoi(size); err != nil || n <= 0 {
+				return fmt.Errorf("invalid array length %q", size)
+			}
+		}
+		return validateType(typeStr[:open])
+	}
+
+	if isTuple, components, err := abi.IsTuple(typeStr); err != nil {
+		return err
+	} else if isTuple {
+		for _, component := range components {
+			if err := validateType(component); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch typeStr {
+	case "address", "bool", "string", "bytes", "int", "uint":
+		return nil
+	}
+	if size, ok := strings.CutPrefix(typeStr, "bytes"); ok {
+		if n, err := strconv.Atoi(size); err == nil && n >= 1 && n <= 32 {
+			return nil
+		}
+	}
+	if size, ok := strings.CutPrefix(strings.TrimPrefix(typeStr, "u"), "int"); ok {
+		if n, err := strconv.Atoi(size); err == nil && n >= 8 && n <= 256 && n%8 == 0 {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown type %q", typeStr)
+}
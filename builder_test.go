@@ -0,0 +1,109 @@
+package multicall
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCallBuilder(t *testing.T) {
+	token := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	holder := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	b := NewCallBuilder()
+	b.Add(token, "balanceOf(address)", &holder).Returns("uint256").Label("balance")
+	b.Add(token, "deposit()").Value(big.NewInt(5)).AllowFailure()
+	b.AddCallData(token, []byte{0x31, 0x3c, 0xe5, 0x67})
+
+	calls, err := b.CallsWithFailure()
+	if err != nil {
+		t.Fatalf("CallsWithFailure: %v", err)
+	}
+	if len(calls) != 3 || b.Len() != 3 {
+		t.Fatalf("got %d calls, want 3", len(calls))
+	}
+	if calls[0].FuncSignature != "balanceOf(address)" || calls[0].ReturnTypes[0] != "uint256" || !calls[0].RequireSuccess {
+		t.Fatalf("call 0 = %+v", calls[0])
+	}
+	if calls[1].Value.Int64() != 5 || calls[1].RequireSuccess {
+		t.Fatalf("call 1 = %+v", calls[1])
+	}
+	if calls[2].CallData == nil {
+		t.Fatalf("call 2 = %+v, want raw call data", calls[2])
+	}
+	if i, ok := b.IndexOf("balance"); !ok || i != 0 {
+		t.Fatalf("IndexOf(balance) = %d, %v", i, ok)
+	}
+
+	results, err := newCallResults([]any{common.LeftPadBytes([]byte{7}, 32), []byte{}, []byte{}}, calls)
+	if err != nil {
+		t.Fatalf("newCallResults: %v", err)
+	}
+	if results[0].Label != "balance" || results[1].Label != "" {
+		t.Fatalf("labels = %q, %q", results[0].Label, results[1].Label)
+	}
+
+	plain, err := b.Calls()
+	if err != nil || len(plain) != 3 || plain[1].Value.Int64() != 5 {
+		t.Fatalf("Calls = %+v, %v", plain, err)
+	}
+	if _, _, err := plain.ToArray(true, false); err != nil {
+		t.Fatalf("ToArray: %v", err)
+	}
+}
+
+func TestCallBuilder_Errors(t *testing.T) {
+	token := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+
+	tests := map[string]struct {
+		build func(b *CallBuilder)
+		want  string
+	}{
+		"bad signature": {
+			build: func(b *CallBuilder) { b.Add(token, "balanceOf") },
+			want:  "call 0: invalid signature",
+		},
+		"argument count": {
+			build: func(b *CallBuilder) { b.Add(token, "totalSupply()").Add(token, "balanceOf(address)") },
+			want:  "call 1: balanceOf(address) takes 1 arguments, got 0",
+		},
+		"modifier before add": {
+			build: func(b *CallBuilder) { b.Returns("uint256") },
+			want:  "Returns called before any call was added",
+		},
+		"return type": {
+			build: func(b *CallBuilder) { b.Add(token, "a()").Returns("uint256", "(address,uint255)[]") },
+			want:  `call 0: invalid return type "(address,uint255)[]"`,
+		},
+		"duplicate label": {
+			build: func(b *CallBuilder) { b.Add(token, "a()").Label("x").Add(token, "b()").Label("x") },
+			want:  `call 1: label "x" already used by call 0`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := NewCallBuilder()
+			tt.build(b)
+
+			if _, err := b.Calls(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateType(t *testing.T) {
+	for _, typeStr := range []string{"address", "uint", "int8", "bytes32", "string[]", "(uint256,bytes)[2]", "((address,bool)[],uint8)"} {
+		if err := validateType(typeStr); err != nil {
+			t.Errorf("validateType(%q) = %v", typeStr, err)
+		}
+	}
+	for _, typeStr := range []string{"", "uint7", "int264", "bytes0", "bytes33", "uint256[0]", "uint256[", "(address,foo)", "addres"} {
+		if err := validateType(typeStr); err == nil {
+			t.Errorf("validateType(%q) = nil, want an error", typeStr)
+		}
+	}
+}
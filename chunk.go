@@ -0,0 +1,193 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/omnes-tech/abi"
+)
+
+// ChunkOptions bounds the batches sent by SimulateCall, AggregateStatic,
+// TryAggregateStatic and TryAggregateStatic3, so they stay under node gas caps,
+// response size limits and the deployless init code size limit. Batches over
+// any limit are split into chunks that run against the same block, and their
+// results are stitched back in the original order. Zero values disable a limit.
+//
+// Batches are also kept under the largest payload the node accepted when the
+// MultiCall was built with WithDetector, see Capabilities.MaxCalldataBytes.
+//
+// Simulated calls only see the state changes of the calls in their own chunk.
+type ChunkOptions struct {
+	MaxCalls int
+	// MaxCalldataBytes bounds the summed calldata of the calls in a chunk.
+	MaxCalldataBytes int
+	// MaxGas bounds the summed gas of the calls in a chunk, as estimated by
+	// eth_estimateGas. Calls that cannot be estimated get a chunk of their own.
+	MaxGas uint64
+	// Concurrency is the number of chunks in flight at once, 1 when unset.
+	Concurrency int
+}
+
+func (c ChunkOptions) enabled() bool {
+	return c.MaxCalls > 0 || c.MaxCalldataBytes > 0 || c.MaxGas > 0
+}
+
+// callEncodingOverhead is an upper bound of the ABI encoding added to the call
+// data of each call in an aggregate: its offset, target, value or allowFailure
+// flag, and the offset, length and padding of its call data.
+const callEncodingOverhead = 7 * 32
+
+// emptyPayloadBytes is an upper bound of the payload of an empty batch: the
+// deployless bytecode and its call type, selector and array headers.
+var emptyPayloadBytes = len(common.FromHex(DEPLOYLESS_MULTICALL_BYTECODE)) + 8*32
+
+// chunkBounds splits calls into [lo, hi) ranges honoring m.Chunking and the
+// detected payload limit of the node. A call exceeding a limit on its own still
+// gets a chunk.
+func (m *MultiCall) chunkBounds(
+	ctx context.Context, calls CallsInterface, client Backend, from *common.Address,
+) ([][2]int, error) {
+	var maxPayload int
+	if m.capabilities != nil {
+		maxPayload = m.capabilities.MaxCalldataBytes
+	}
+	if !m.Chunking.enabled() && maxPayload == 0 || calls.Len() == 0 {
+		return [][2]int{{0, calls.Len()}}, nil
+	}
+
+	var bounds [][2]int
+	lo, size, gas, payload := 0, 0, uint64(0), emptyPayloadBytes
+	for i := 0; i < calls.Len(); i++ {
+		callData, err := encodeCallData(calls, i)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding call %d: %w", i, err)
+		}
+
+		var callGas uint64
+		if m.Chunking.MaxGas > 0 {
+			callGas, err = estimateCallGas(ctx, calls, i, callData, client, from)
+			if err != nil {
+				return nil, fmt.Errorf("error estimating gas of call %d: %w", i, err)
+			}
+		}
+
+		callPayload := callEncodingOverhead + len(callData)
+		full := i > lo &&
+			(m.Chunking.MaxCalls > 0 && i-lo >= m.Chunking.MaxCalls ||
+				m.Chunking.MaxCalldataBytes > 0 && size+len(callData) > m.Chunking.MaxCalldataBytes ||
+				m.Chunking.MaxGas > 0 && gas+callGas > m.Chunking.MaxGas ||
+				maxPayload > 0 && payload+callPayload > maxPayload)
+		if full {
+			bounds = append(bounds, [2]int{lo, i})
+			lo, size, gas, payload = i, 0, 0, emptyPayloadBytes
+		}
+
+		size += len(callData)
+		gas += callGas
+		payload += callPayload
+	}
+
+	return append(bounds, [2]int{lo, calls.Len()}), nil
+}
+
+func encodeCallData(calls CallsInterface, i int) ([]byte, error) {
+	if callData := calls.GetCallData(i); callData != nil {
+		return callData, nil
+	}
+
+	return abi.EncodeWithSignature(calls.GetFuncSignature(i), calls.GetArgs(i)...)
+}
+
+// estimateCallGas estimates the i-th call alone. A call the node refuses to
+// estimate, e.g. because it reverts, is reported as using all of MaxGas.
+func estimateCallGas(
+	ctx context.Context, calls CallsInterface, i int, callData []byte, client Backend, from *common.Address,
+) (uint64, error) {
+	msg := ethereum.CallMsg{To: calls.GetTarget(i), Data: callData, Value: calls.GetValue(i)}
+	if from != nil {
+		msg.From = *from
+	}
+
+	gas, err := client.EstimateGas(ctx, msg)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return ^uint64(0) >> 1, nil
+	}
+
+	return gas, nil
+}
+
+// runChunks runs every chunk at the same block, fetching the latest one when
+// blockNumber is nil, and stitches their results.
+func (m *MultiCall) runChunks(
+	ctx context.Context, client Backend, blockNumber *big.Int, bounds [][2]int,
+	run func(ctx context.Context, lo, hi int, blockNumber *big.Int) Result,
+) Result {
+	if blockNumber == nil {
+		latest, err := client.BlockNumber(ctx)
+		if err != nil {
+			return Result{Success: false, Error: fmt.Errorf("error getting block number: %w", err)}
+		}
+		blockNumber = new(big.Int).SetUint64(latest)
+	}
+
+	concurrency := m.Chunking.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]Result, len(bounds))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, b := range bounds {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = run(ctx, b[0], b[1], blockNumber)
+		}()
+	}
+	wg.Wait()
+
+	return stitchResults(results, bounds)
+}
+
+// stitchResults merges chunk results, re-indexing calls to their position in
+// the original batch. The first failing chunk fails the whole result.
+func stitchResults(results []Result, bounds [][2]int) Result {
+	stitched := Result{Success: true, TxOrCall: results[0].TxOrCall}
+
+	var callResults []CallResult
+	for i, result := range results {
+		if result.Error != nil {
+			var callFailed *CallFailedError
+			if errors.As(result.Error, &callFailed) {
+				callFailed.Index += bounds[i][0]
+			}
+
+			return Result{
+				Success:  false,
+				Error:    fmt.Errorf("error in calls %d to %d: %w", bounds[i][0], bounds[i][1]-1, result.Error),
+				TxOrCall: result.TxOrCall,
+			}
+		}
+
+		for _, callResult := range result.CallResults() {
+			callResult.Index += bounds[i][0]
+			callResults = append(callResults, callResult)
+		}
+		stitched.Success = stitched.Success && result.Success
+	}
+
+	stitched.Result = callResults
+	return stitched
+}
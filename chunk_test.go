@@ -0,0 +1,144 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/omnes-tech/abi"
+)
+
+// aggregateStaticBackend answers aggregateStatic calls with each call's position
+// in its batch, recording the block and size of every batch it serves.
+func aggregateStaticBackend(t *testing.T) (*fakeBackend, *[]string, *[]int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var blocks []string
+	var sizes []int
+	backend := &fakeBackend{
+		blockNumber: 42,
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			call := args[0].(CallArgs)
+			decoded, err := abi.Decode([]string{"(address,bytes)[]"}, call.Data[4:])
+			if err != nil {
+				return err
+			}
+			batch := decoded[0].([]any)
+
+			returnData := make([]any, len(batch))
+			for i := range batch {
+				returnData[i], _ = abi.Encode([]string{"uint256"}, big.NewInt(int64(i)))
+			}
+			encoded, err := abi.Encode([]string{"bytes[]"}, returnData)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			blocks = append(blocks, args[1].(string))
+			sizes = append(sizes, len(batch))
+			mu.Unlock()
+
+			*result.(*hexutil.Bytes) = encoded
+			return nil
+		},
+	}
+
+	return backend, &blocks, &sizes
+}
+
+func TestMultiCall_Chunking(t *testing.T) {
+	targets := make([]common.Address, 5)
+	returnTypes := make([][]string, 5)
+	for i := range targets {
+		targets[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+		returnTypes[i] = []string{"uint256"}
+	}
+	calls := NewCalls(targets, []string{"a()", "a()", "a()", "a()", "a()"}, nil, nil, returnTypes, nil)
+
+	t.Run("max calls", func(t *testing.T) {
+		backend, blocks, sizes := aggregateStaticBackend(t)
+		m, err := NewMultiCall(backend, nil, WithDeployedMode(), WithChunking(ChunkOptions{MaxCalls: 2, Concurrency: 2}))
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		result := m.AggregateStatic(calls, backend, nil, nil, nil)
+		if !result.Success {
+			t.Fatalf("AggregateStatic: %v", result.Error)
+		}
+		if len(*sizes) != 3 {
+			t.Fatalf("batches = %v, want 3", *sizes)
+		}
+		for _, block := range *blocks {
+			if block != "0x2a" {
+				t.Fatalf("blocks = %v, want all pinned to 0x2a", *blocks)
+			}
+		}
+
+		callResults := result.CallResults()
+		if len(callResults) != 5 {
+			t.Fatalf("got %d call results, want 5", len(callResults))
+		}
+		for i, callResult := range callResults {
+			positionInChunk, err := DecodeCall[uint64](result, i)
+			if err != nil {
+				t.Fatalf("DecodeCall(%d): %v", i, err)
+			}
+			if callResult.Index != i || callResult.Target != targets[i] || positionInChunk != uint64(i%2) {
+				t.Fatalf("call %d = %v, position %d", i, callResult, positionInChunk)
+			}
+		}
+	})
+
+	t.Run("max calldata bytes", func(t *testing.T) {
+		backend, _, sizes := aggregateStaticBackend(t)
+		m, err := NewMultiCall(backend, nil, WithDeployedMode(), WithChunking(ChunkOptions{MaxCalldataBytes: 12}))
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		if result := m.AggregateStatic(calls, backend, nil, big.NewInt(7), nil); !result.Success {
+			t.Fatalf("AggregateStatic: %v", result.Error)
+		}
+		if want := []int{3, 2}; len(*sizes) != 2 || (*sizes)[0] != want[0] || (*sizes)[1] != want[1] {
+			t.Fatalf("batches = %v, want %v", *sizes, want)
+		}
+	})
+
+	t.Run("failed chunk reports original index", func(t *testing.T) {
+		revert := encodeRevert(t, "MultiCall__StaticCallFailed(uint256)", big.NewInt(1))
+		backend := &fakeBackend{
+			callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				return &revertErr{revert}
+			},
+		}
+		m, err := NewMultiCall(backend, nil, WithDeployedMode(), WithChunking(ChunkOptions{MaxCalls: 2}))
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		result := m.AggregateStatic(calls, backend, nil, nil, nil)
+		var callFailed *CallFailedError
+		if !errors.As(result.Error, &callFailed) || callFailed.Index != 1 || callFailed.Target != targets[1] {
+			t.Fatalf("Error = %v, want call 1 to fail", result.Error)
+		}
+	})
+
+	t.Run("no limits sends one batch", func(t *testing.T) {
+		backend, _, sizes := aggregateStaticBackend(t)
+		m, err := NewMultiCall(backend, nil, WithDeployedMode())
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		if result := m.AggregateStatic(calls, backend, nil, nil, nil); !result.Success || len(*sizes) != 1 {
+			t.Fatalf("batches = %v, err = %v", *sizes, result.Error)
+		}
+	})
+}
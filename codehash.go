@@ -0,0 +1,50 @@
+package multicall
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// OMNES_CODE_HASHES maps the runtime code hash of each released version of the
+// Omnes MultiCall contract to its name. They are trusted by default.
+var OMNES_CODE_HASHES = map[common.Hash]string{}
+
+var (
+	codeHashesMu sync.RWMutex
+	// codeHashes maps the runtime code hash of each genuine Omnes MultiCall
+	// version to its name, OMNES_CODE_HASHES and the registered ones.
+	codeHashes = maps.Clone(OMNES_CODE_HASHES)
+)
+
+// RegisterCodeHash marks hash as the runtime code hash of a genuine version of
+// the Omnes MultiCall contract, e.g. a build deployed on a private network, in
+// addition to OMNES_CODE_HASHES. NewMultiCall only trusts contracts whose code
+// hash is known and falls back to deployless calls for any other code.
+func RegisterCodeHash(hash common.Hash, version string) {
+	codeHashesMu.Lock()
+	defer codeHashesMu.Unlock()
+
+	codeHashes[hash] = version
+}
+
+// CodeVersion returns the version whose runtime code hash matches code.
+func CodeVersion(code []byte) (string, bool) {
+	codeHashesMu.RLock()
+	defer codeHashesMu.RUnlock()
+
+	version, ok := codeHashes[crypto.Keccak256Hash(code)]
+	return version, ok
+}
+
+// trustedCode reports whether code can be used as the multicall contract, which
+// is only code with a known hash.
+func trustedCode(code []byte) (string, bool) {
+	if len(code) == 0 {
+		return "", false
+	}
+
+	return CodeVersion(code)
+}
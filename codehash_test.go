@@ -0,0 +1,95 @@
+package multicall
+
+import (
+	"bytes"
+	"log"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// registerCode registers the hash of code as version for the duration of the test.
+func registerCode(t *testing.T, code []byte, version string) {
+	t.Helper()
+
+	codeHashesMu.RLock()
+	saved := maps.Clone(codeHashes)
+	codeHashesMu.RUnlock()
+	t.Cleanup(func() {
+		codeHashesMu.Lock()
+		codeHashes = saved
+		codeHashesMu.Unlock()
+	})
+
+	RegisterCodeHash(crypto.Keccak256Hash(code), version)
+}
+
+func TestNewMultiCall_CodeHash(t *testing.T) {
+	genuine := []byte{0x60, 0x80, 0x60, 0x40}
+	registerCode(t, genuine, "v1")
+
+	t.Run("registered code is trusted", func(t *testing.T) {
+		backend := &fakeBackend{code: map[common.Address][]byte{OMNES_MULTICALL_ADDRESS: genuine}}
+
+		m, err := NewMultiCall(backend, nil)
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+		if !m.IsDeployed() || m.Version != "v1" {
+			t.Fatalf("ContractAddress = %v, Version = %q; want deployed v1", m.ContractAddress, m.Version)
+		}
+	})
+
+	t.Run("unknown code falls back to deployless", func(t *testing.T) {
+		backend := &fakeBackend{code: map[common.Address][]byte{OMNES_MULTICALL_ADDRESS: {0xfe}}}
+		var buf bytes.Buffer
+
+		m, err := NewMultiCall(backend, nil, WithLogger(log.New(&buf, "", 0)))
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+		if !m.IsDeployless() || m.Version != "" {
+			t.Fatalf("ContractAddress = %v, Version = %q; want deployless", m.ContractAddress, m.Version)
+		}
+		if !strings.Contains(buf.String(), "does not match a known multicall version") {
+			t.Fatalf("log = %q", buf.String())
+		}
+	})
+}
+
+func TestTrustedCode(t *testing.T) {
+	if _, ok := trustedCode([]byte{0xfe}); ok {
+		t.Fatalf("code without a registered hash is trusted")
+	}
+	if _, ok := trustedCode(nil); ok {
+		t.Fatalf("empty code is trusted")
+	}
+
+	registerCode(t, []byte{0xfe}, "v1")
+	if version, ok := trustedCode([]byte{0xfe}); !ok || version != "v1" {
+		t.Fatalf("trustedCode = %q, %v; want v1", version, ok)
+	}
+}
+
+func TestRegisterCodeHash(t *testing.T) {
+	code := []byte{0x60, 0x01}
+	registerCode(t, code, "custom")
+
+	if version, ok := CodeVersion(code); !ok || version != "custom" {
+		t.Fatalf("CodeVersion = %q, %v; want custom", version, ok)
+	}
+	if _, ok := OMNES_CODE_HASHES[crypto.Keccak256Hash(code)]; ok {
+		t.Fatalf("RegisterCodeHash changed the released hashes")
+	}
+
+	codeHashesMu.RLock()
+	defer codeHashesMu.RUnlock()
+	for hash, version := range OMNES_CODE_HASHES {
+		if codeHashes[hash] != version {
+			t.Fatalf("released %s (%s) is not trusted", version, hash)
+		}
+	}
+}
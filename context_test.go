@@ -0,0 +1,80 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// hangingClient returns a client whose node never answers until the test ends.
+func hangingClient(t *testing.T) *EthClientBackend {
+	t.Helper()
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(done)
+		server.Close()
+	})
+
+	client, err := Dial(server.URL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	return client
+}
+
+func TestMultiCall_Context_Deadline(t *testing.T) {
+	client := hangingClient(t)
+	target := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	calls := NewCalls(
+		[]common.Address{target}, []string{"totalSupply()"}, nil, nil, [][]string{{"uint256"}}, nil,
+	)
+
+	t.Run("deployless", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		m := &MultiCall{}
+		res := m.AggregateStaticContext(ctx, calls, client, nil, big.NewInt(1), nil)
+
+		if res.Success || !errors.Is(res.Error, context.DeadlineExceeded) {
+			t.Fatalf("Error = %v, want context.DeadlineExceeded", res.Error)
+		}
+	})
+
+	t.Run("deployed", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		m := &MultiCall{ContractAddress: &OMNES_MULTICALL_ADDRESS}
+		res := m.ChainDataContext(ctx, client, big.NewInt(1))
+
+		if res.Success || !errors.Is(res.Error, context.DeadlineExceeded) {
+			t.Fatalf("Error = %v, want context.DeadlineExceeded", res.Error)
+		}
+	})
+
+	t.Run("canceled constructor", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := NewMultiCallContext(ctx, client, nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	})
+}
@@ -0,0 +1,313 @@
+package multicall
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// DecodeError reports a decoded value that cannot be stored in the requested Go type.
+type DecodeError struct {
+	// Index is the call index, or -1 when decoding values not tied to a call.
+	Index int
+	// Path locates the offending value, e.g. "[1].Amount".
+	Path  string
+	Value any
+	Type  reflect.Type
+}
+
+func (e *DecodeError) Error() string {
+	var location string
+	if e.Index >= 0 {
+		location = fmt.Sprintf("call %d: ", e.Index)
+	}
+	if e.Path != "" {
+		location += e.Path + ": "
+	}
+
+	return fmt.Sprintf("%scannot decode %T into %s", location, e.Value, e.Type)
+}
+
+var (
+	bigIntType  = reflect.TypeOf(big.Int{})
+	addressType = reflect.TypeOf(common.Address{})
+)
+
+func toBytes(v any) []byte {
+	switch b := v.(type) {
+	case []byte:
+		return b
+	case string:
+		decoded, err := hexutil.Decode(Add0xPrefix(b))
+		if err != nil {
+			return nil
+		}
+		return decoded
+	}
+	return nil
+}
+
+// CallResults returns the per-call results of r, or nil when r was not
+// produced by a call aggregating method.
+func (r *Result) CallResults() []CallResult {
+	callResults, _ := r.Result.([]CallResult)
+	return callResults
+}
+
+// CallCount is the number of calls whose outcome is recorded in r.
+func (r *Result) CallCount() int {
+	return len(r.CallResults())
+}
+
+// Decode decodes the return data of the i-th call into v, which must be a
+// non-nil pointer, using the call's ReturnTypes.
+func (r *Result) Decode(i int, v any) error {
+	callResults := r.CallResults()
+	if i < 0 || i >= len(callResults) {
+		return fmt.Errorf("call %d: index out of range, result holds %d calls", i, len(callResults))
+	}
+
+	return callResults[i].Decode(v)
+}
+
+// Decode decodes the return data of c into v, which must be a non-nil pointer,
+// using the call's ReturnTypes.
+func (c *CallResult) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("call %d: decode target must be a non-nil pointer, got %T", c.Index, v)
+	}
+	if !c.Success {
+		return fmt.Errorf("call %d: call failed, nothing to decode: %w", c.Index, c.Revert)
+	}
+	if c.Decoded == nil {
+		return fmt.Errorf("call %d: no return types set", c.Index)
+	}
+
+	if err := assignValues(c.Decoded, rv.Elem()); err != nil {
+		return withCallIndex(err, c.Index)
+	}
+
+	return nil
+}
+
+// DecodeCall decodes the return data of the i-th call of r into a T.
+func DecodeCall[T any](r Result, i int) (T, error) {
+	var v T
+	err := r.Decode(i, &v)
+	return v, err
+}
+
+// DecodeAll decodes the return data of every call of r into a T.
+func DecodeAll[T any](r Result) ([]T, error) {
+	decoded := make([]T, r.CallCount())
+	for i := range decoded {
+		if err := r.Decode(i, &decoded[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return decoded, nil
+}
+
+// DecodeInto converts ABI decoded values into a T. A single value is converted
+// directly (scalars, slices, tuples into structs); several values fill the
+// exported fields of a struct in order.
+func DecodeInto[T any](values []any) (T, error) {
+	var v T
+	err := assignValues(values, reflect.ValueOf(&v).Elem())
+	return v, err
+}
+
+func assignValues(values []any, dst reflect.Value) error {
+	if dst.Kind() == reflect.Ptr && isStruct(dst.Type()) {
+		elem := reflect.New(dst.Type().Elem())
+		if err := assignValues(values, elem.Elem()); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	if len(values) == 1 {
+		err := assign(values[0], dst, "")
+		if err == nil || !isStruct(dst.Type()) {
+			return err
+		}
+	}
+
+	if !isStruct(dst.Type()) {
+		return &DecodeError{Index: -1, Value: values, Type: dst.Type()}
+	}
+
+	return assignStruct(values, dst, "")
+}
+
+func assign(value any, dst reflect.Value, path string) error {
+	t := dst.Type()
+
+	if t.Kind() == reflect.Interface && reflect.TypeOf(value) != nil && reflect.TypeOf(value).Implements(t) {
+		dst.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	if t.Kind() == reflect.Ptr {
+		if value == nil {
+			dst.Set(reflect.Zero(t))
+			return nil
+		}
+		if b, ok := value.(*big.Int); ok && t.Elem() == bigIntType {
+			dst.Set(reflect.ValueOf(new(big.Int).Set(b)))
+			return nil
+		}
+
+		elem := reflect.New(t.Elem())
+		if err := assign(value, elem.Elem(), path); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	mismatch := &DecodeError{Index: -1, Path: path, Value: value, Type: t}
+
+	switch {
+	case t == bigIntType:
+		b, ok := value.(*big.Int)
+		if !ok {
+			return mismatch
+		}
+		dst.Set(reflect.ValueOf(*new(big.Int).Set(b)))
+		return nil
+	case t == addressType:
+		switch a := value.(type) {
+		case string:
+			if !common.IsHexAddress(a) {
+				return mismatch
+			}
+			dst.Set(reflect.ValueOf(common.HexToAddress(a)))
+		case common.Address:
+			dst.Set(reflect.ValueOf(a))
+		case *common.Address:
+			dst.Set(reflect.ValueOf(*a))
+		default:
+			return mismatch
+		}
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return mismatch
+		}
+		dst.SetBool(b)
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return mismatch
+		}
+		dst.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b, ok := value.(*big.Int)
+		if !ok || !b.IsInt64() || dst.OverflowInt(b.Int64()) {
+			return mismatch
+		}
+		dst.SetInt(b.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b, ok := value.(*big.Int)
+		if !ok || !b.IsUint64() || dst.OverflowUint(b.Uint64()) {
+			return mismatch
+		}
+		dst.SetUint(b.Uint64())
+	case reflect.Slice:
+		if b, ok := value.([]byte); ok && t.Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(append([]byte{}, b...))
+			return nil
+		}
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch
+		}
+		slice := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := assign(item, slice.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+	case reflect.Array:
+		if b, ok := value.([]byte); ok && t.Elem().Kind() == reflect.Uint8 {
+			if len(b) != t.Len() {
+				return mismatch
+			}
+			reflect.Copy(dst, reflect.ValueOf(b))
+			return nil
+		}
+		items, ok := value.([]any)
+		if !ok || len(items) != t.Len() {
+			return mismatch
+		}
+		for i, item := range items {
+			if err := assign(item, dst.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch
+		}
+		return assignStruct(items, dst, path)
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return mismatch
+		}
+		dst.Set(reflect.ValueOf(&value).Elem())
+	default:
+		return mismatch
+	}
+
+	return nil
+}
+
+func assignStruct(values []any, dst reflect.Value, path string) error {
+	t := dst.Type()
+
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			fields = append(fields, i)
+		}
+	}
+	if len(fields) != len(values) {
+		return &DecodeError{Index: -1, Path: path, Value: values, Type: t}
+	}
+
+	for i, field := range fields {
+		if err := assign(values[i], dst.Field(field), path+"."+t.Field(field).Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != bigIntType && t != addressType
+}
+
+func withCallIndex(err error, i int) error {
+	if decodeErr, ok := err.(*DecodeError); ok {
+		decodeErr.Index = i
+		return decodeErr
+	}
+	return fmt.Errorf("call %d: %w", i, err)
+}
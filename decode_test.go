@@ -0,0 +1,146 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/omnes-tech/abi"
+)
+
+func TestDecodeInto(t *testing.T) {
+	holder := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	t.Run("scalar", func(t *testing.T) {
+		got, err := DecodeInto[uint64]([]any{big.NewInt(42)})
+		if err != nil || got != 42 {
+			t.Fatalf("got %d, %v; want 42", got, err)
+		}
+	})
+
+	t.Run("big int pointer is copied", func(t *testing.T) {
+		value := big.NewInt(7)
+		got, err := DecodeInto[*big.Int]([]any{value})
+		if err != nil || got.Int64() != 7 || got == value {
+			t.Fatalf("got %v, %v; want a copy of 7", got, err)
+		}
+	})
+
+	t.Run("several values fill struct fields in order", func(t *testing.T) {
+		type reserves struct {
+			Owner   common.Address
+			Amount  *big.Int
+			Active  bool
+			private int
+		}
+
+		got, err := DecodeInto[reserves]([]any{holder.Hex(), big.NewInt(5), true})
+		if err != nil {
+			t.Fatalf("DecodeInto: %v", err)
+		}
+		if got.Owner != holder || got.Amount.Int64() != 5 || !got.Active {
+			t.Fatalf("got %+v", got)
+		}
+	})
+
+	t.Run("tuple and arrays", func(t *testing.T) {
+		type pair struct {
+			A uint8
+			B [2]byte
+		}
+
+		got, err := DecodeInto[[]pair]([]any{[]any{
+			[]any{big.NewInt(1), []byte{0xaa, 0xbb}},
+			[]any{big.NewInt(2), []byte{0xcc, 0xdd}},
+		}})
+		if err != nil {
+			t.Fatalf("DecodeInto: %v", err)
+		}
+		if len(got) != 2 || got[1].A != 2 || got[1].B != [2]byte{0xcc, 0xdd} {
+			t.Fatalf("got %+v", got)
+		}
+	})
+
+	t.Run("overflow is a mismatch", func(t *testing.T) {
+		_, err := DecodeInto[uint8]([]any{big.NewInt(256)})
+
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) || decodeErr.Index != -1 {
+			t.Fatalf("err = %v, want *DecodeError", err)
+		}
+	})
+
+	t.Run("mismatch reports the path", func(t *testing.T) {
+		type s struct {
+			Amount *big.Int
+			Name   string
+		}
+
+		_, err := DecodeInto[s]([]any{big.NewInt(1), big.NewInt(2)})
+
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) || decodeErr.Path != ".Name" {
+			t.Fatalf("err = %v, want mismatch at .Name", err)
+		}
+	})
+}
+
+func TestResult_DecodeCall(t *testing.T) {
+	first, _ := abi.Encode([]string{"uint256"}, big.NewInt(1000))
+	second, _ := abi.Encode([]string{"uint256", "bool"}, big.NewInt(3), true)
+	encoded, _ := abi.Encode([]string{"bytes[]"}, []any{first, second})
+
+	backend := &fakeBackend{
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			*result.(*hexutil.Bytes) = encoded
+			return nil
+		},
+	}
+
+	token := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	calls := NewCalls(
+		[]common.Address{token, token},
+		[]string{"totalSupply()", "decimals()"},
+		nil,
+		nil,
+		[][]string{{"uint256"}, {"uint256", "bool"}},
+		nil,
+	)
+
+	m := &MultiCall{ContractAddress: &OMNES_MULTICALL_ADDRESS}
+	res := m.AggregateStatic(calls, backend, nil, big.NewInt(1), nil)
+	if !res.Success {
+		t.Fatalf("AggregateStatic: %v", res.Error)
+	}
+
+	supply, err := DecodeCall[*big.Int](res, 0)
+	if err != nil || supply.Int64() != 1000 {
+		t.Fatalf("call 0 = %v, %v; want 1000", supply, err)
+	}
+
+	type flagged struct {
+		Value uint16
+		Flag  bool
+	}
+	got, err := DecodeCall[flagged](res, 1)
+	if err != nil || got.Value != 3 || !got.Flag {
+		t.Fatalf("call 1 = %+v, %v", got, err)
+	}
+
+	_, err = DecodeCall[string](res, 1)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Index != 1 {
+		t.Fatalf("err = %v, want *DecodeError for call 1", err)
+	}
+
+	if _, err := DecodeCall[uint64](res, 2); err == nil {
+		t.Fatal("expected out of range error")
+	}
+
+	if _, err := DecodeAll[*big.Int](res); err == nil {
+		t.Fatal("expected DecodeAll to reject the two-value call")
+	}
+}
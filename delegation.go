@@ -0,0 +1,277 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+)
+
+// Delegation makes the aggregate write methods execute the calls from the
+// signer's EOA itself, so targets see the EOA as msg.sender. The EOA is
+// delegated to Implementation with an EIP-7702 SetCode transaction calling the
+// EOA with the encoded batch. Failed calls are handled by Implementation, so
+// requireSuccess and RequireSuccess are checked against the preview: the
+// transaction is not sent when a call required to succeed fails in it.
+type Delegation struct {
+	// Implementation is the batch executor the EOA delegates to. It must only
+	// let the EOA itself execute batches.
+	Implementation common.Address
+	// EncodeBatch builds the call to Implementation executing calls, ExecuteBatchCallData when nil.
+	EncodeBatch func(calls Calls) ([]byte, error)
+}
+
+func (d *Delegation) encodeBatch(calls Calls) ([]byte, error) {
+	if d.EncodeBatch == nil {
+		return ExecuteBatchCallData(calls)
+	}
+	return d.EncodeBatch(calls)
+}
+
+// SignAuthorization signs an EIP-7702 authorization delegating the signer's
+// account to address. signer must implement HashSigner.
+func SignAuthorization(
+	signer SignerInterface, chainID *big.Int, address common.Address, nonce uint64,
+) (types.SetCodeAuthorization, error) {
+	hashSigner, ok := signer.(HashSigner)
+	if !ok {
+		return types.SetCodeAuthorization{}, ErrNoHashSigner
+	}
+
+	auth := types.SetCodeAuthorization{Address: address, Nonce: nonce}
+	auth.ChainID.SetFromBig(chainID)
+
+	encoded, err := rlp.EncodeToBytes([]any{auth.ChainID, auth.Address, auth.Nonce})
+	if err != nil {
+		return types.SetCodeAuthorization{}, err
+	}
+
+	// authorizations sign keccak256(0x05 || rlp([chain_id, address, nonce]))
+	signature, err := hashSigner.SignHash(crypto.Keccak256Hash([]byte{0x05}, encoded))
+	if err != nil {
+		return types.SetCodeAuthorization{}, fmt.Errorf("error signing authorization: %w", err)
+	}
+
+	auth.R.SetBytes(signature[:32])
+	auth.S.SetBytes(signature[32:64])
+	auth.V = signature[64] - 27
+
+	return auth, nil
+}
+
+// delegatedAggregateCalls previews calls from the EOA of from, the signer's one
+// when nil, with the EOA code overridden by the implementation. Writes are sent
+// by sendDelegatedCalls.
+func (m *MultiCall) delegatedAggregateCalls(
+	ctx context.Context, calls []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	if from == nil && m.Signer != nil {
+		from = (*m.Signer).GetAddress()
+	}
+	_, result := m.previewDelegatedCalls(ctx, calls, client, from, blockNumber, overrides)
+	return result
+}
+
+// previewDelegatedCalls runs calls from the EOA from with its code overridden by
+// the implementation, and returns the encoded batch with the preview Result.
+// The batch returns nothing, so the result of each call is read from a
+// SIMULATE_CALL run as the code of the EOA, and the preview fails when a call
+// with RequireSuccess set failed in it.
+func (m *MultiCall) previewDelegatedCalls(
+	ctx context.Context, withFailure []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int,
+	overrides StateOverride,
+) ([]byte, Result) {
+	if from == nil {
+		return nil, Result{Success: false, Error: fmt.Errorf("no account to execute the delegated calls from")}
+	}
+	calls := CallsWithFailure(withFailure).toCalls()
+
+	callData, err := m.Delegation.encodeBatch(calls)
+	if err != nil {
+		return nil, Result{Success: false, Error: fmt.Errorf("error encoding batch: %w", err)}
+	}
+
+	implementationCode, err := client.CodeAt(ctx, m.Delegation.Implementation, blockNumber)
+	if err != nil {
+		return nil, Result{Success: false, Error: fmt.Errorf("error getting bytecode: %w", err)}
+	}
+	if len(implementationCode) == 0 {
+		return nil, Result{Success: false, Error: fmt.Errorf("no code at delegation implementation %s", m.Delegation.Implementation.Hex())}
+	}
+
+	previewOverrides := StateOverride{*from: {Code: hexutil.Bytes(implementationCode)}}
+	previewOverrides.Add(overrides)
+
+	_, call, err := readContract(ctx, client, from, from, nil, callData, blockNumber, previewOverrides)
+	txOrCall := FromCallToTxOrCall(call, blockNumber, previewOverrides)
+	if err != nil {
+		return nil, Result{
+			Success:  false,
+			Error:    fmt.Errorf("error calling contract: %w", decodeMultiCallError(err, Calls(calls))),
+			TxOrCall: txOrCall,
+		}
+	}
+
+	entries, _, err := deploylessSimulateAt(ctx, calls, *from, client, blockNumber, overrides)
+	if err != nil {
+		return nil, Result{Success: false, Error: err, TxOrCall: txOrCall}
+	}
+
+	callResults, err := newCallResults(entries, Calls(calls))
+	if err != nil {
+		return nil, Result{Success: false, Error: err, TxOrCall: txOrCall}
+	}
+
+	return callData, requireCalls(Result{Success: true, Result: callResults, TxOrCall: txOrCall}, withFailure, false)
+}
+
+// sendDelegatedCalls previews calls from the signer's EOA and broadcasts their
+// transaction, to the EOA itself, carrying an authorization unless the EOA is
+// already delegated to the implementation. It is waited for before returning
+// when wait is set.
+func (m *MultiCall) sendDelegatedCalls(
+	ctx context.Context, calls []CallWithFailure, client Backend, blockNumber *big.Int, overrides StateOverride, wait bool,
+) (*PendingTx, Result) {
+	from := *(*m.Signer).GetAddress()
+
+	callData, preview := m.previewDelegatedCalls(ctx, calls, client, &from, blockNumber, overrides)
+	if preview.Error != nil {
+		return nil, preview
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, Result{Success: false, Error: fmt.Errorf("error getting chain id: %w", err)}
+	}
+
+	signedTx, err := m.delegatedTransaction(ctx, client, chainID, from, callData)
+	if err != nil {
+		return nil, Result{Success: false, Error: err}
+	}
+
+	if err := broadcastTransaction(ctx, client, signedTx, m.Nonces); err != nil {
+		return nil, Result{
+			Success:  false,
+			Error:    fmt.Errorf("error sending signed transaction: %w", err),
+			TxOrCall: FromTxToTxOrCall(signedTx, from, nil, nil),
+		}
+	}
+	mined := func(receipt *types.Receipt, signedTx *types.Transaction) Result {
+		return Result{
+			Success:  receipt.Status == types.ReceiptStatusSuccessful,
+			Result:   preview.Result,
+			TxOrCall: FromTxToTxOrCall(signedTx, from, receipt.BlockNumber, nil),
+		}
+	}
+
+	sign := signWith(*m.Signer, chainID)
+	pending := newPendingTx(signedTx, from)
+	if wait {
+		pending.settle(ctx, client, signedTx, from, m.Replacement, sign, mined)
+	} else {
+		go pending.settle(context.WithoutCancel(ctx), client, signedTx, from, m.Replacement, sign, mined)
+	}
+
+	return pending, Result{}
+}
+
+// delegatedTransaction builds and signs the transaction from the EOA to itself,
+// a SetCode transaction unless the EOA is already delegated to the
+// implementation. The authorization nonce follows the transaction nonce, since
+// the sender's nonce is bumped before authorizations are applied, so both are
+// reserved together and released together on failure.
+func (m *MultiCall) delegatedTransaction(
+	ctx context.Context, client Backend, chainID *big.Int, eoa common.Address, callData []byte,
+) (_ *types.Transaction, err error) {
+	signer := *m.Signer
+
+	code, err := client.CodeAt(ctx, eoa, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting bytecode: %w", err)
+	}
+	delegated := bytes.Equal(code, types.AddressToDelegation(m.Delegation.Implementation))
+
+	reserved := uint64(1)
+	if !delegated {
+		reserved = 2
+	}
+	nonce, err := m.Nonces.NextConsecutive(ctx, client, eoa, reserved)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			for i := uint64(0); i < reserved; i++ {
+				m.Nonces.Release(eoa, nonce+i)
+			}
+		}
+	}()
+
+	var authList []types.SetCodeAuthorization
+	if !delegated {
+		auth, err := SignAuthorization(signer, chainID, m.Delegation.Implementation, nonce+1)
+		if err != nil {
+			return nil, err
+		}
+		authList = append(authList, auth)
+	}
+
+	feeCap, tipCap, err := suggestFees(ctx, client, m.Fees)
+	if err != nil {
+		return nil, err
+	}
+	if tipCap == nil {
+		tipCap = feeCap
+	}
+
+	gas, err := client.EstimateGas(ctx, ethereum.CallMsg{
+		From:              eoa,
+		To:                &eoa,
+		GasFeeCap:         feeCap,
+		GasTipCap:         tipCap,
+		Data:              callData,
+		AuthorizationList: authList,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error estimating gas: %w", err)
+	}
+
+	// SetCode transactions need at least one authorization
+	var txData types.TxData = &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        &eoa,
+		Data:      callData,
+	}
+	if len(authList) > 0 {
+		txData = &types.SetCodeTx{
+			ChainID:   uint256.MustFromBig(chainID),
+			Nonce:     nonce,
+			GasTipCap: uint256.MustFromBig(tipCap),
+			GasFeeCap: uint256.MustFromBig(feeCap),
+			Gas:       gas,
+			To:        eoa,
+			Value:     new(uint256.Int),
+			Data:      callData,
+			AuthList:  authList,
+		}
+	}
+
+	signedTx, err := signer.SignTx(types.NewTx(txData), chainID)
+	if err != nil {
+		return nil, fmt.Errorf("error signing transaction: %w", err)
+	}
+
+	return signedTx, nil
+}
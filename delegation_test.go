@@ -0,0 +1,226 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/omnes-tech/abi"
+)
+
+// countingSigner counts the transactions it signs.
+type countingSigner struct {
+	*GenericSigner
+	signed int
+}
+
+func (s *countingSigner) SignTx(tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	s.signed++
+	return s.GenericSigner.SignTx(tx, chainId)
+}
+
+func TestSignAuthorization(t *testing.T) {
+	signer, _ := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	implementation := common.HexToAddress("0x0000000000000000000000000000000000001d01")
+
+	auth, err := SignAuthorization(signer, big.NewInt(1), implementation, 4)
+	if err != nil {
+		t.Fatalf("SignAuthorization: %v", err)
+	}
+
+	authority, err := auth.Authority()
+	if err != nil || authority != *signer.GetAddress() {
+		t.Fatalf("Authority = %s (%v), want %s", authority, err, signer.GetAddress())
+	}
+	if auth.Address != implementation || auth.Nonce != 4 || auth.ChainID.Uint64() != 1 {
+		t.Fatalf("auth = %+v", auth)
+	}
+}
+
+func TestDelegatedAggregateCalls(t *testing.T) {
+	generic, _ := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	counting := &countingSigner{GenericSigner: generic.(*GenericSigner)}
+	var signer SignerInterface = counting
+	eoa := *signer.GetAddress()
+	implementation := common.HexToAddress("0x0000000000000000000000000000000000000bac")
+	implementationCode := []byte{0x60, 0x80}
+	okData, _ := abi.Encode([]string{"uint256"}, big.NewInt(7))
+	simulation := encodeRevert(t, "MultiCall__Simulation((bool,bytes,uint256)[])", []any{
+		[]any{true, okData, big.NewInt(21000)},
+	})
+
+	var preview, simulated CallArgs
+	var previewOverrides, simulatedOverrides StateOverride
+	backend := &fakeBackend{
+		blockNumber: 10,
+		gasPrice:    big.NewInt(3),
+		gas:         90000,
+		nonce:       7,
+		code:        map[common.Address][]byte{implementation: implementationCode},
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			call, overrides := args[0].(CallArgs), args[2].(StateOverride)
+			// the per-call simulation runs as the EOA code, with no call data
+			if len(call.Data) == 0 {
+				simulated, simulatedOverrides = call, overrides
+				return &revertErr{simulation}
+			}
+			preview, previewOverrides = call, overrides
+			*result.(*hexutil.Bytes) = nil
+			return nil
+		},
+	}
+	m, err := NewMultiCall(backend, &signer, WithDeploylessMode(), WithDelegation(Delegation{Implementation: implementation}))
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCalls([]common.Address{target}, []string{"a()"}, nil, nil, [][]string{{"uint256"}}, []*big.Int{big.NewInt(5)})
+	wantData, _ := ExecuteBatchCallData(calls)
+
+	t.Run("preview runs the batch from the EOA", func(t *testing.T) {
+		result := m.AggregateCalls(calls, backend, &eoa, nil, true, nil)
+		if result.Error != nil {
+			t.Fatalf("Error = %v", result.Error)
+		}
+		if preview.From != eoa || *preview.To != eoa || !bytes.Equal(preview.Data, wantData) {
+			t.Fatalf("preview = %+v, want executeBatch from and to the EOA", preview)
+		}
+		if !bytes.Equal(previewOverrides[eoa].Code, implementationCode) {
+			t.Fatalf("EOA code was not overridden: %v", previewOverrides)
+		}
+		if len(backend.sent) != 0 {
+			t.Fatalf("a transaction was sent for a preview")
+		}
+
+		callResults := result.CallResults()
+		if len(callResults) != 1 || !callResults[0].Success || callResults[0].Decoded[0].(*big.Int).Int64() != 7 {
+			t.Fatalf("CallResults = %v, want the decoded result of the call", callResults)
+		}
+		if simulated.From != eoa || *simulated.To != eoa || (*big.Int)(simulated.Value).Int64() != 5 {
+			t.Fatalf("simulation = %+v, want a call from and to the EOA carrying the value", simulated)
+		}
+		if !bytes.HasPrefix(simulatedOverrides[eoa].Code, common.FromHex(DEPLOYLESS_MULTICALL_BYTECODE)) {
+			t.Fatalf("EOA code was not overridden by the simulation: %v", simulatedOverrides)
+		}
+	})
+
+	t.Run("preview defaults to the signer's EOA", func(t *testing.T) {
+		result := m.AggregateCalls(calls, backend, nil, nil, true, nil)
+		if result.Error != nil {
+			t.Fatalf("Error = %v", result.Error)
+		}
+		if preview.From != eoa || *preview.To != eoa {
+			t.Fatalf("preview = %+v, want executeBatch from and to the EOA", preview)
+		}
+	})
+
+	t.Run("write sends a SetCode transaction", func(t *testing.T) {
+		result := m.AggregateCalls(calls, backend, nil, nil, false, nil)
+		if result.Error != nil || !result.Success {
+			t.Fatalf("Error = %v", result.Error)
+		}
+		if len(backend.sent) != 1 {
+			t.Fatalf("sent %d transactions, want 1", len(backend.sent))
+		}
+
+		tx := backend.sent[0]
+		if tx.Type() != types.SetCodeTxType || *tx.To() != eoa || tx.Nonce() != 7 || !bytes.Equal(tx.Data(), wantData) {
+			t.Fatalf("tx = type %d to %s nonce %d", tx.Type(), tx.To(), tx.Nonce())
+		}
+		sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), tx)
+		if err != nil || sender != eoa {
+			t.Fatalf("Sender = %s (%v), want %s", sender, err, eoa)
+		}
+
+		authList := tx.SetCodeAuthorizations()
+		if len(authList) != 1 || authList[0].Address != implementation || authList[0].Nonce != 8 {
+			t.Fatalf("AuthList = %+v, want a delegation to the implementation with nonce 8", authList)
+		}
+		if authority, _ := authList[0].Authority(); authority != eoa {
+			t.Fatalf("Authority = %s, want %s", authority, eoa)
+		}
+		if counting.signed != 1 {
+			t.Fatalf("signer signed %d transactions, want 1", counting.signed)
+		}
+		if callResults := result.CallResults(); len(callResults) != 1 || callResults[0].Decoded[0].(*big.Int).Int64() != 7 {
+			t.Fatalf("CallResults = %v, want the previewed results", callResults)
+		}
+	})
+
+	t.Run("nonce manager reserves the authorization nonce", func(t *testing.T) {
+		nonces := NewNonceManager()
+		managed, err := NewMultiCall(
+			backend, &signer, WithDeploylessMode(), WithDelegation(Delegation{Implementation: implementation}), WithNonceManager(nonces),
+		)
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		if result := managed.AggregateCalls(calls, backend, nil, nil, false, nil); result.Error != nil {
+			t.Fatalf("Error = %v", result.Error)
+		}
+		tx := backend.sent[len(backend.sent)-1]
+		if got, _ := nonces.Next(context.Background(), backend, eoa); got != 9 {
+			t.Fatalf("Next = %d, want 9 past the transaction and authorization nonces", got)
+		}
+		nonces.Release(eoa, 9)
+
+		nonces.broadcastFailed(eoa, errors.New("insufficient funds for gas * price + value"), txNonces(tx, eoa)...)
+		if got, _ := nonces.NextConsecutive(context.Background(), backend, eoa, 2); got != 7 {
+			t.Fatalf("NextConsecutive = %d, want both nonces of the rejected transaction released", got)
+		}
+	})
+
+	t.Run("required calls are checked against the preview", func(t *testing.T) {
+		succeeded := simulation
+		defer func() { simulation = succeeded }()
+		simulation = encodeRevert(t, "MultiCall__Simulation((bool,bytes,uint256)[])", []any{
+			[]any{false, []byte{}, big.NewInt(21000)},
+		})
+		sent := len(backend.sent)
+
+		writes := map[string]func() Result{
+			"aggregate":     func() Result { return m.AggregateCalls(calls, backend, nil, nil, false, nil) },
+			"try aggregate": func() Result { return m.TryAggregateCalls(calls, true, backend, nil, nil, false, nil) },
+			"try aggregate 3": func() Result {
+				return m.TryAggregateCalls3([]CallWithFailure{{Call: calls[0], RequireSuccess: true}}, backend, nil, nil, false, nil)
+			},
+			"try aggregate preview": func() Result { return m.TryAggregateCalls(calls, true, backend, nil, nil, true, nil) },
+		}
+		for name, write := range writes {
+			var failed *CallFailedError
+			if result := write(); !errors.As(result.Error, &failed) || failed.Index != 0 || failed.Target != target || result.Success {
+				t.Fatalf("%s: Result = %+v, want the required call to fail", name, result)
+			}
+		}
+		if len(backend.sent) != sent {
+			t.Fatalf("a transaction was sent although a required call failed")
+		}
+
+		result := m.TryAggregateCalls3([]CallWithFailure{{Call: calls[0]}}, backend, nil, nil, false, nil)
+		if result.Error != nil || !result.Success || len(backend.sent) != sent+1 {
+			t.Fatalf("Result = %+v, want the transaction sent when failures are allowed", result)
+		}
+		if callResults := result.CallResults(); len(callResults) != 1 || callResults[0].Success {
+			t.Fatalf("CallResults = %v, want the failed call reported", callResults)
+		}
+	})
+
+	t.Run("plain transaction once delegated", func(t *testing.T) {
+		backend.code[eoa] = types.AddressToDelegation(implementation)
+		defer delete(backend.code, eoa)
+
+		if result := m.AggregateCalls(calls, backend, nil, nil, false, nil); result.Error != nil {
+			t.Fatalf("Error = %v", result.Error)
+		}
+		if tx := backend.sent[len(backend.sent)-1]; tx.Type() != types.DynamicFeeTxType {
+			t.Fatalf("tx type = %d, want a dynamic fee transaction", tx.Type())
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DETERMINISTIC_DEPLOYER_ADDRESS is the CREATE2 factory deployed at the same
+// address on most EVM chains. It takes a 32 bytes salt followed by the init code.
+var DETERMINISTIC_DEPLOYER_ADDRESS = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+
+// OMNES_MULTICALL_INIT_CODE is the creation code of the Omnes MultiCall
+// contract, deployed by DETERMINISTIC_DEPLOYER_ADDRESS with OMNES_MULTICALL_SALT
+// to OMNES_MULTICALL_ADDRESS. Its runtime code hash must be in
+// OMNES_CODE_HASHES. DeployMultiCall refuses to run with the default options
+// while it is empty.
+var OMNES_MULTICALL_INIT_CODE = ""
+
+var OMNES_MULTICALL_SALT = common.Hash{}
+
+var ErrNoDeployer = errors.New("no deterministic deployer on this chain")
+
+// DeployOptions describes a deterministic deployment of the Omnes MultiCall
+// contract. The zero value deploys OMNES_MULTICALL_INIT_CODE to OMNES_MULTICALL_ADDRESS.
+type DeployOptions struct {
+	// InitCode is the creation code to deploy instead of OMNES_MULTICALL_INIT_CODE,
+	// with Salt. The same InitCode and Salt give the same address on every chain.
+	InitCode []byte
+	Salt     common.Hash
+	// Deployer is the CREATE2 factory, DETERMINISTIC_DEPLOYER_ADDRESS when nil.
+	Deployer *common.Address
+}
+
+func (d DeployOptions) deployer() common.Address {
+	if d.Deployer == nil {
+		return DETERMINISTIC_DEPLOYER_ADDRESS
+	}
+	return *d.Deployer
+}
+
+// initCode returns the creation code and salt to deploy, the bundled ones
+// unless InitCode is set.
+func (d DeployOptions) initCode() ([]byte, common.Hash) {
+	if d.InitCode == nil {
+		return common.FromHex(OMNES_MULTICALL_INIT_CODE), OMNES_MULTICALL_SALT
+	}
+	return d.InitCode, d.Salt
+}
+
+// Address returns the address the contract is deployed to.
+func (d DeployOptions) Address() common.Address {
+	initCode, salt := d.initCode()
+	return crypto.CreateAddress2(d.deployer(), salt, crypto.Keccak256(initCode))
+}
+
+func DeployMultiCall(
+	client Backend, signer *SignerInterface, deploy DeployOptions, opts ...Option,
+) (*MultiCall, error) {
+	return DeployMultiCallContext(context.Background(), client, signer, deploy, opts...)
+}
+
+// DeployMultiCallContext deploys the Omnes MultiCall contract through the
+// CREATE2 deployer, waits for the receipt, verifies the deployed runtime code
+// hash against the known ones and returns a MultiCall bound to it, built with
+// opts. The runtime the init code would deploy is checked with an eth_call
+// before anything is sent. Nothing is sent when the contract is already
+// deployed at deploy.Address().
+func DeployMultiCallContext(
+	ctx context.Context, client Backend, signer *SignerInterface, deploy DeployOptions, opts ...Option,
+) (*MultiCall, error) {
+	if initCode, _ := deploy.initCode(); len(initCode) == 0 {
+		return nil, fmt.Errorf("no init code to deploy: OMNES_MULTICALL_INIT_CODE is not bundled, set DeployOptions.InitCode")
+	}
+
+	o := options{nonces: NewNonceManager()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	address := deploy.Address()
+	code, err := client.CodeAt(ctx, address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting bytecode: %w", err)
+	}
+
+	if len(code) == 0 {
+		if signer == nil {
+			return nil, ErrNoSigner
+		}
+		if err := sendDeployment(ctx, client, *signer, deploy, o.fees, o.replacement, o.nonces); err != nil {
+			return nil, err
+		}
+	}
+
+	version, err := verifyDeployment(ctx, client, address)
+	if err != nil {
+		return nil, err
+	}
+
+	// the MultiCall continues the nonces of the deployment
+	opts = append(opts, WithContractAddress(address), WithDeployedMode(), WithNonceManager(o.nonces))
+	m, err := NewMultiCallContext(ctx, client, signer, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m.Version = version
+
+	return m, nil
+}
+
+func sendDeployment(
+	ctx context.Context, client Backend, signer SignerInterface, deploy DeployOptions,
+	fees FeeStrategy, replacement ReplacementPolicy, nonces *NonceManager,
+) error {
+	deployer := deploy.deployer()
+	deployerCode, err := client.CodeAt(ctx, deployer, nil)
+	if err != nil {
+		return fmt.Errorf("error getting bytecode: %w", err)
+	}
+	if len(deployerCode) == 0 {
+		return fmt.Errorf("%w: no code at %s", ErrNoDeployer, deployer.Hex())
+	}
+
+	chainId, err := client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+
+	initCode, salt := deploy.initCode()
+	if err := checkInitCode(ctx, client, signer.GetAddress(), initCode); err != nil {
+		return err
+	}
+
+	callData := append(salt.Bytes(), initCode...)
+	tx, err := createTransaction(ctx, client, chainId, signer.GetAddress(), &deployer, nil, callData, fees, nonces)
+	if err != nil {
+		return fmt.Errorf("error creating deployment transaction: %w", err)
+	}
+
+	signedTx, err := signer.SignTx(tx, chainId)
+	if err != nil {
+		nonces.Release(*signer.GetAddress(), tx.Nonce())
+		return err
+	}
+
+	receipt, signedTx, _, err := sendSignedTransaction(ctx, client, signedTx, replacement, signWith(signer, chainId), nonces)
+	if err != nil {
+		return fmt.Errorf("error sending deployment transaction: %w", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("deployment transaction %s reverted", signedTx.Hash())
+	}
+
+	return nil
+}
+
+// checkInitCode runs initCode as a contract creation call and checks the
+// runtime code it returns has a known code hash, so no gas is spent deploying
+// code NewMultiCall would not trust.
+func checkInitCode(ctx context.Context, client Backend, from *common.Address, initCode []byte) error {
+	runtime, _, err := readContract(ctx, client, from, nil, nil, initCode, nil, nil)
+	if err != nil {
+		return fmt.Errorf("error simulating deployment: %w", err)
+	}
+
+	if _, ok := trustedCode(runtime); !ok {
+		return fmt.Errorf("init code deploys code that does not match a known multicall version (hash %s)", crypto.Keccak256Hash(runtime).Hex())
+	}
+
+	return nil
+}
+
+// verifyDeployment checks the code at address has a registered runtime code
+// hash, and returns its version.
+func verifyDeployment(ctx context.Context, client Backend, address common.Address) (string, error) {
+	code, err := client.CodeAt(ctx, address, nil)
+	if err != nil {
+		return "", fmt.Errorf("error getting bytecode: %w", err)
+	}
+	if len(code) == 0 {
+		return "", fmt.Errorf("%w: no code at %s after deployment", ErrNoMulticallContract, address.Hex())
+	}
+
+	version, ok := trustedCode(code)
+	if !ok {
+		return "", fmt.Errorf("code at %s does not match a known multicall version (hash %s)", address.Hex(), crypto.Keccak256Hash(code).Hex())
+	}
+
+	return version, nil
+}
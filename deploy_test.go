@@ -0,0 +1,146 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestDeployOptions_Address(t *testing.T) {
+	// first example of EIP-1014
+	deploy := DeployOptions{InitCode: []byte{0x00}, Deployer: &common.Address{}}
+
+	if got, want := deploy.Address(), common.HexToAddress("0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"); got != want {
+		t.Fatalf("Address = %s, want %s", got, want)
+	}
+}
+
+func TestDeployOptions_BundledAddress(t *testing.T) {
+	if OMNES_MULTICALL_INIT_CODE == "" {
+		t.Skip("OMNES_MULTICALL_INIT_CODE is not bundled")
+	}
+
+	if got := (DeployOptions{}).Address(); got != OMNES_MULTICALL_ADDRESS {
+		t.Fatalf("Address = %s, want %s", got, OMNES_MULTICALL_ADDRESS)
+	}
+}
+
+func TestDeployMultiCall(t *testing.T) {
+	deploy := DeployOptions{InitCode: []byte{0x60, 0x80}, Salt: common.HexToHash("0x01")}
+
+	t.Run("already deployed", func(t *testing.T) {
+		registerCode(t, []byte{0x60, 0x80}, "v1")
+		backend := &fakeBackend{code: map[common.Address][]byte{deploy.Address(): {0x60, 0x80}}}
+
+		m, err := DeployMultiCall(backend, nil, deploy)
+		if err != nil {
+			t.Fatalf("DeployMultiCall: %v", err)
+		}
+		if *m.ContractAddress != deploy.Address() || m.Version != "v1" {
+			t.Fatalf("ContractAddress = %s, Version = %q; want %s, v1", m.ContractAddress, m.Version, deploy.Address())
+		}
+	})
+
+	t.Run("unknown runtime", func(t *testing.T) {
+		registerCode(t, []byte{0x60, 0x80}, "v1")
+		backend := &fakeBackend{code: map[common.Address][]byte{deploy.Address(): {0x60, 0x00}}}
+
+		if _, err := DeployMultiCall(backend, nil, deploy); err == nil || !strings.Contains(err.Error(), "does not match a known multicall version") {
+			t.Fatalf("err = %v, want the unknown runtime to be refused", err)
+		}
+	})
+
+	t.Run("bundled init code", func(t *testing.T) {
+		saved := OMNES_MULTICALL_INIT_CODE
+		t.Cleanup(func() { OMNES_MULTICALL_INIT_CODE = saved })
+
+		OMNES_MULTICALL_INIT_CODE = ""
+		if _, err := DeployMultiCall(&fakeBackend{}, nil, DeployOptions{}); err == nil {
+			t.Fatalf("DeployMultiCall ran without init code")
+		}
+
+		// first example of EIP-1014
+		OMNES_MULTICALL_INIT_CODE = "0x00"
+		bundled := DeployOptions{Deployer: &common.Address{}}
+		if got, want := bundled.Address(), common.HexToAddress("0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"); got != want {
+			t.Fatalf("Address = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("sends the deployment and verifies it", func(t *testing.T) {
+		registerCode(t, []byte{0x60, 0x80}, "v1")
+		signer, _ := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+
+		var backend *fakeBackend
+		backend = &fakeBackend{
+			blockNumber: 3,
+			gasPrice:    big.NewInt(1),
+			gas:         300000,
+			code:        map[common.Address][]byte{DETERMINISTIC_DEPLOYER_ADDRESS: {0x60, 0x00}},
+			callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				// the creation call returns the runtime code
+				*result.(*hexutil.Bytes) = []byte{0x60, 0x80}
+				return nil
+			},
+			mined: func(tx *types.Transaction) bool {
+				backend.code[deploy.Address()] = []byte{0x60, 0x80}
+				return true
+			},
+		}
+
+		m, err := DeployMultiCall(backend, &signer, deploy)
+		if err != nil {
+			t.Fatalf("DeployMultiCall: %v", err)
+		}
+		if *m.ContractAddress != deploy.Address() || m.Version != "v1" {
+			t.Fatalf("ContractAddress = %s, Version = %q; want %s, v1", m.ContractAddress, m.Version, deploy.Address())
+		}
+
+		if len(backend.sent) != 1 {
+			t.Fatalf("sent %d transactions, want 1", len(backend.sent))
+		}
+		tx := backend.sent[0]
+		if *tx.To() != DETERMINISTIC_DEPLOYER_ADDRESS || !bytes.Equal(tx.Data(), append(deploy.Salt.Bytes(), deploy.InitCode...)) {
+			t.Fatalf("tx to %s with data %x, want the salt and init code sent to the deployer", tx.To(), tx.Data())
+		}
+	})
+
+	t.Run("unknown init code is not sent", func(t *testing.T) {
+		registerCode(t, []byte{0x60, 0x80}, "v1")
+		signer, _ := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+		backend := &fakeBackend{
+			gasPrice: big.NewInt(1),
+			code:     map[common.Address][]byte{DETERMINISTIC_DEPLOYER_ADDRESS: {0x60, 0x00}},
+			callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				*result.(*hexutil.Bytes) = []byte{0x60, 0x00}
+				return nil
+			},
+		}
+
+		_, err := DeployMultiCall(backend, &signer, deploy)
+		if err == nil || !strings.Contains(err.Error(), "does not match a known multicall version") {
+			t.Fatalf("err = %v, want the unknown runtime to be refused", err)
+		}
+		if len(backend.sent) != 0 {
+			t.Fatalf("the deployment was sent")
+		}
+	})
+
+	t.Run("no deployer", func(t *testing.T) {
+		signer, err := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+		if err != nil {
+			t.Fatalf("NewSigner: %v", err)
+		}
+
+		if _, err := DeployMultiCall(&fakeBackend{}, &signer, deploy); !errors.Is(err, ErrNoDeployer) {
+			t.Fatalf("err = %v, want ErrNoDeployer", err)
+		}
+	})
+}
@@ -7,55 +7,77 @@ import (
 	"math/big"
 	"strings"
 
-	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/omnes-tech/abi"
 )
 
-func transactWithFailure(
-	calls CallsWithFailure, requireSuccess bool, client *ethclient.Client,
-	signer SignerInterface, to *common.Address, funcSignature string, txReturnTypes []string,
-	withValue bool, isMultiCall3Type bool,
-) Result {
-	return write(
-		calls,
-		requireSuccess,
-		client,
-		signer,
-		to,
-		funcSignature,
-		txReturnTypes,
-		withValue,
-		isMultiCall3Type,
-	)
-}
+// sendWrite broadcasts the transaction calling funcSignature on to with calls and returns it pending, or the
+// failed Result when it could not be sent. The transaction is waited for before
+// returning when wait is set, and in the background otherwise.
+func sendWrite(
+	ctx context.Context, calls CallsInterface, requireSuccess bool, client Backend, signer SignerInterface,
+	fees FeeStrategy, replacement ReplacementPolicy, nonces *NonceManager, to *common.Address, funcSignature string, txReturnTypes []string,
+	withValue bool, isMultiCall3Type bool, wait bool,
+) (*PendingTx, Result) {
+	chainId, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, Result{Success: false, Error: err}
+	}
 
-func transact(
-	calls Calls, requireSuccess bool, client *ethclient.Client,
-	signer SignerInterface, to *common.Address, funcSignature string, txReturnTypes []string,
-	withValue bool, isMultiCall3Type bool,
-) Result {
-	return write(
-		calls,
-		requireSuccess,
-		client,
-		signer,
-		to,
-		funcSignature,
-		txReturnTypes,
-		withValue,
-		isMultiCall3Type,
+	signedTx, encodedCallResult, result := buildWrite(
+		ctx, calls, requireSuccess, client, signer, chainId, fees, nonces, to, funcSignature, withValue, isMultiCall3Type,
 	)
+	if signedTx == nil {
+		return nil, result
+	}
+
+	if err := broadcastTransaction(ctx, client, signedTx, nonces); err != nil {
+		return nil, Result{
+			Success:  false,
+			Error:    fmt.Errorf("error sending signed transaction: %w", err),
+			TxOrCall: FromTxToTxOrCall(signedTx, *signer.GetAddress(), nil, nil),
+		}
+	}
+
+	from := *signer.GetAddress()
+	mined := func(receipt *types.Receipt, signedTx *types.Transaction) Result {
+		callResults, err := decodeWriteResults(txReturnTypes, encodedCallResult, calls)
+		if err != nil {
+			return Result{
+				Success:  false,
+				Error:    err,
+				TxOrCall: FromTxToTxOrCall(signedTx, from, receipt.BlockNumber, nil),
+			}
+		}
+
+		return Result{
+			Success:  receipt.Status == 1,
+			Result:   callResults,
+			TxOrCall: FromTxToTxOrCall(signedTx, from, receipt.BlockNumber, nil),
+		}
+	}
+
+	pending := newPendingTx(signedTx, from)
+	if wait {
+		pending.settle(ctx, client, signedTx, from, replacement, signWith(signer, chainId), mined)
+	} else {
+		go pending.settle(context.WithoutCancel(ctx), client, signedTx, from, replacement, signWith(signer, chainId), mined)
+	}
+
+	return pending, Result{}
 }
 
-func write(
-	calls CallsInterface, requireSuccess bool, client *ethclient.Client, signer SignerInterface,
-	to *common.Address, funcSignature string, txReturnTypes []string, withValue bool, isMultiCall3Type bool,
-) Result {
+// buildWrite creates and signs the transaction of write, and pre-flights it with
+// a call whose encoded result is returned. The failed Result is returned, with
+// a nil transaction, when the transaction could not be built or would fail.
+func buildWrite(
+	ctx context.Context, calls CallsInterface, requireSuccess bool, client Backend, signer SignerInterface, chainId *big.Int,
+	fees FeeStrategy, nonces *NonceManager, to *common.Address, funcSignature string, withValue bool, isMultiCall3Type bool,
+) (*types.Transaction, []byte, Result) {
 	arrayfiedCalls, msgValue, err := calls.ToArray(withValue, isMultiCall3Type)
 	if err != nil {
-		return Result{Success: false, Error: err}
+		return nil, nil, Result{Success: false, Error: err}
 	}
 
 	var callData []byte
@@ -65,69 +87,62 @@ func write(
 		callData, err = abi.EncodeWithSignature(funcSignature, arrayfiedCalls)
 	}
 	if err != nil {
-		return Result{Success: false, Error: err}
+		return nil, nil, Result{Success: false, Error: err}
 	}
 
-	tx, err := createTransaction(client, signer.GetAddress(), to, msgValue, callData)
+	tx, err := createTransaction(ctx, client, chainId, signer.GetAddress(), to, msgValue, callData, fees, nonces)
 	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: FromTxToTxOrCall(tx, *signer.GetAddress(), nil, nil)}
-	}
-
-	chainId, err := client.ChainID(context.Background())
-	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: FromTxToTxOrCall(tx, *signer.GetAddress(), nil, nil)}
+		return nil, nil, Result{Success: false, Error: err}
 	}
 
 	signedTx, err := signer.SignTx(tx, chainId)
 	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: FromTxToTxOrCall(tx, *signer.GetAddress(), nil, nil)}
+		nonces.Release(*signer.GetAddress(), tx.Nonce())
+		return nil, nil, Result{Success: false, Error: err, TxOrCall: FromTxToTxOrCall(tx, *signer.GetAddress(), nil, nil)}
 	}
 
-	encodedCallResult, err := client.CallContract(context.Background(), ethereum.CallMsg{
-		From: *signer.GetAddress(),
-		To:   to,
-		Data: callData,
-	}, nil)
+	encodedCallResult, _, err := readContract(ctx, client, signer.GetAddress(), to, msgValue, callData, nil, nil)
 	if err != nil {
-		blockNumber, err := client.BlockNumber(context.Background())
+		nonces.Release(*signer.GetAddress(), tx.Nonce())
+
+		blockNumber, err := client.BlockNumber(ctx)
 		if err != nil {
-			return Result{Success: false, Error: err, TxOrCall: FromTxToTxOrCall(tx, *signer.GetAddress(), nil, nil)}
+			return nil, nil, Result{Success: false, Error: err, TxOrCall: FromTxToTxOrCall(tx, *signer.GetAddress(), nil, nil)}
 		}
 
-		return Result{
+		return nil, nil, Result{
 			Success:  false,
-			Error:    fmt.Errorf("error calling contract: %w, with data: %s", err, common.Bytes2Hex(callData)),
+			Error:    fmt.Errorf("error calling contract: %w, with data: %s", decodeMultiCallError(err, calls), common.Bytes2Hex(callData)),
 			TxOrCall: FromTxToTxOrCall(tx, *signer.GetAddress(), big.NewInt(int64(blockNumber)), nil),
 		}
 	}
 
-	receipt, err := sendSignedTransaction(client, signedTx)
+	return signedTx, encodedCallResult, Result{}
+}
+
+// decodeWriteResults decodes the call results of an aggregate write from the
+// encoded result of its pre-flight call.
+func decodeWriteResults(txReturnTypes []string, encodedCallResult []byte, calls CallsInterface) ([]CallResult, error) {
+	decodedCallResult, err := safeDecode(txReturnTypes, encodedCallResult)
 	if err != nil {
-		return Result{
-			Success:  false,
-			Error:    fmt.Errorf("error sending signed transaction: %w", err),
-			TxOrCall: FromTxToTxOrCall(tx, *signer.GetAddress(), receipt.BlockNumber, nil),
-		}
+		return nil, fmt.Errorf("error decoding call result: %w", err)
 	}
 
-	decodedCallResult, err := abi.Decode(txReturnTypes, encodedCallResult)
+	callResults, err := newCallResults(decodedCallResult[0].([]any), calls)
 	if err != nil {
-		return Result{
-			Success:  false,
-			Error:    fmt.Errorf("error decoding call result: %w", err),
-			TxOrCall: FromTxToTxOrCall(tx, *signer.GetAddress(), receipt.BlockNumber, nil),
-		}
+		return nil, fmt.Errorf("error decoding call result: %w", err)
 	}
 
-	return parseResults(decodedCallResult, receipt.Status == 1, receipt, FromTxToTxOrCall(signedTx, *signer.GetAddress(), receipt.BlockNumber, nil))
+	return callResults, nil
 }
 
 func txAsReadWithFailure(
-	calls CallsWithFailure, requireSuccess bool, client *ethclient.Client, from *common.Address, to *common.Address,
+	ctx context.Context, calls CallsWithFailure, requireSuccess bool, client Backend, from *common.Address, to *common.Address,
 	funcSignature string, txReturnTypes []string, blockNumber *big.Int,
 	overrides StateOverride,
 ) Result {
 	return asRead(
+		ctx,
 		calls,
 		requireSuccess,
 		client,
@@ -141,11 +156,12 @@ func txAsReadWithFailure(
 }
 
 func txAsRead(
-	calls Calls, requireSuccess bool, client *ethclient.Client, from *common.Address, to *common.Address,
+	ctx context.Context, calls Calls, requireSuccess bool, client Backend, from *common.Address, to *common.Address,
 	funcSignature string, txReturnTypes []string, blockNumber *big.Int,
 	overrides StateOverride,
 ) Result {
 	return asRead(
+		ctx,
 		calls,
 		requireSuccess,
 		client,
@@ -159,7 +175,7 @@ func txAsRead(
 }
 
 func asRead(
-	calls CallsInterface, requireSuccess bool, client *ethclient.Client, from *common.Address, to *common.Address,
+	ctx context.Context, calls CallsInterface, requireSuccess bool, client Backend, from *common.Address, to *common.Address,
 	funcSignature string, txReturnTypes []string, blockNumber *big.Int,
 	overrides StateOverride,
 ) Result {
@@ -178,7 +194,8 @@ func asRead(
 		return Result{Success: false, Error: err}
 	}
 
-	decodedCallResult, decodedAggregatedCallsResultVar, call, err := makeCall(
+	callResults, call, err := makeCall(
+		ctx,
 		calls,
 		client,
 		from,
@@ -195,15 +212,16 @@ func asRead(
 		return Result{Success: false, Error: err, TxOrCall: call}
 	}
 
-	return parseResults(decodedAggregatedCallsResultVar, true, decodedCallResult, call)
+	return Result{Success: true, Result: callResults, TxOrCall: call}
 }
 
 func call(
-	calls Calls, requireSuccess bool, client *ethclient.Client, from *common.Address, to *common.Address, funcSignature string,
+	ctx context.Context, calls Calls, requireSuccess bool, client Backend, from *common.Address, to *common.Address, funcSignature string,
 	txReturnTypes []string, multicallAddress *common.Address,
 	blockNumber *big.Int, isSimulation bool, withValue bool, overrides StateOverride,
 ) Result {
 	return read(
+		ctx,
 		calls,
 		requireSuccess,
 		client,
@@ -220,11 +238,12 @@ func call(
 }
 
 func callWithFailure(
-	calls CallsWithFailure, client *ethclient.Client, from *common.Address, to *common.Address, funcSignature string,
+	ctx context.Context, calls CallsWithFailure, client Backend, from *common.Address, to *common.Address, funcSignature string,
 	txReturnTypes []string, multicallAddress *common.Address, blockNumber *big.Int,
 	overrides StateOverride,
 ) Result {
 	return read(
+		ctx,
 		calls,
 		false,
 		client,
@@ -241,7 +260,7 @@ func callWithFailure(
 }
 
 func read(
-	calls CallsInterface, requireSuccess bool, client *ethclient.Client, from *common.Address, to *common.Address, funcSignature string,
+	ctx context.Context, calls CallsInterface, requireSuccess bool, client Backend, from *common.Address, to *common.Address, funcSignature string,
 	txReturnTypes []string, multicallAddress *common.Address, blockNumber *big.Int,
 	isSimulation bool, withValue bool, overrides StateOverride,
 ) Result {
@@ -265,7 +284,8 @@ func read(
 		return Result{Success: false, Error: err}
 	}
 
-	decodedCallResult, decodedAggregatedCallsResultVar, call, err := makeCall(
+	callResults, call, err := makeCall(
+		ctx,
 		calls,
 		client,
 		from,
@@ -282,11 +302,11 @@ func read(
 		return Result{Success: false, Error: err, TxOrCall: call}
 	}
 
-	return parseResults(decodedAggregatedCallsResultVar, true, decodedCallResult, call)
+	return Result{Success: true, Result: callResults, TxOrCall: call}
 }
 
 func getData(
-	addresses []*common.Address, client *ethclient.Client, to *common.Address,
+	ctx context.Context, addresses []*common.Address, client Backend, to *common.Address,
 	funcSignature string, returnTypes []string, blockNumber *big.Int,
 ) Result {
 
@@ -301,18 +321,18 @@ func getData(
 		return Result{Success: false, Error: err}
 	}
 
-	encodedCallResult, call, err := readContract(client, &ZERO_ADDRESS, to, nil, callData, blockNumber, nil)
+	encodedCallResult, call, err := readContract(ctx, client, &ZERO_ADDRESS, to, nil, callData, blockNumber, nil)
 	if err != nil {
 		return Result{Success: false, Error: err, TxOrCall: FromCallToTxOrCall(call, blockNumber, nil)}
 	}
 
-	decodedCallResult, err := abi.Decode(returnTypes, encodedCallResult)
+	decodedCallResult, err := safeDecode(returnTypes, encodedCallResult)
 	if err != nil {
 		return Result{Success: false, Error: err, TxOrCall: FromCallToTxOrCall(call, blockNumber, nil)}
 	}
 
 	if blockNumber == nil {
-		blockNumberUint64, err := client.BlockNumber(context.Background())
+		blockNumberUint64, err := client.BlockNumber(ctx)
 		if err != nil {
 			return Result{Success: false, Error: err, TxOrCall: FromCallToTxOrCall(call, blockNumber, nil)}
 		}
@@ -323,18 +343,21 @@ func getData(
 }
 
 func makeCall(
-	calls CallsInterface, client *ethclient.Client, from *common.Address, to *common.Address, value *big.Int, callData []byte, txReturnTypes []string,
+	ctx context.Context, calls CallsInterface, client Backend, from *common.Address, to *common.Address, value *big.Int, callData []byte, txReturnTypes []string,
 	isSimulation bool, multicallAddress *common.Address, blockNumber *big.Int, overrides StateOverride,
-) ([]any, []any, TxOrCall, error) {
+) ([]CallResult, TxOrCall, error) {
 	if !true {
 		log.Println(multicallAddress)
 	}
 
 	var decodedCallResult []any
-	encodedCallResult, call, err := readContract(client, from, to, value, callData, blockNumber, overrides)
+	encodedCallResult, call, err := readContract(ctx, client, from, to, value, callData, blockNumber, overrides)
 	if err != nil && !isSimulation {
-		return nil, nil, TxOrCall{}, err
+		return nil, TxOrCall{}, decodeMultiCallError(err, calls)
 	} else if isSimulation {
+		if err == nil {
+			return nil, FromCallToTxOrCall(call, blockNumber, overrides), ErrNoSimulationResult
+		}
 		if strings.Contains(err.Error(), "execution reverted") {
 			encodedRevert, ok := parseRevertData(err)
 			if ok {
@@ -343,23 +366,15 @@ func makeCall(
 					encodedRevert,
 				)
 				if err != nil {
-					return nil, nil, TxOrCall{}, err
+					return nil, TxOrCall{}, err
 				}
 
 				decodedCallResult = decodedCallResult[0].([]any)
-				for i, result := range decodedCallResult {
-					varValue := result.([]any)[1].([]byte)
-					if len(varValue) > 0 {
-						decodedCallResult[i].([]any)[1] = Add0xPrefix(common.Bytes2Hex(varValue))
-					} else {
-						decodedCallResult[i].([]any)[1] = "0x"
-					}
-				}
 			} else {
-				return nil, nil, TxOrCall{}, fmt.Errorf("error decoding revert reason: %s", common.Bytes2Hex(encodedRevert))
+				return nil, TxOrCall{}, fmt.Errorf("error decoding revert reason: %s", common.Bytes2Hex(encodedRevert))
 			}
 		} else {
-			return nil, nil, FromCallToTxOrCall(call, blockNumber, overrides), fmt.Errorf("error calling contract: %w", err)
+			return nil, FromCallToTxOrCall(call, blockNumber, overrides), fmt.Errorf("error calling contract: %w", decodeMultiCallError(err, calls))
 		}
 	}
 
@@ -368,105 +383,27 @@ func makeCall(
 	}
 
 	if !isSimulation {
-		decodedCallResult, err = abi.Decode(txReturnTypes, encodedCallResult)
+		decodedCallResult, err = safeDecode(txReturnTypes, encodedCallResult)
 		if err != nil {
-			return nil, nil, TxOrCall{}, err
+			return nil, TxOrCall{}, err
 		}
-	}
 
-	for len(decodedCallResult) != calls.Len() {
+		// aggregate methods return a single array holding one entry per call
 		decodedCallResult = decodedCallResult[0].([]any)
 	}
 
-	decodedAggregatedCallsResultVar, err := decodeAggregateCallsResult(decodedCallResult, calls)
+	callResults, err := newCallResults(decodedCallResult, calls)
 	if err != nil {
-		return nil, nil, TxOrCall{}, err
+		return nil, TxOrCall{}, err
 	}
 
 	if blockNumber == nil {
-		blockNumberUint64, err := client.BlockNumber(context.Background())
+		blockNumberUint64, err := client.BlockNumber(ctx)
 		if err != nil {
-			return nil, nil, TxOrCall{}, err
+			return nil, TxOrCall{}, err
 		}
 		blockNumber = big.NewInt(int64(blockNumberUint64))
 	}
 
-	return decodedCallResult, decodedAggregatedCallsResultVar, FromCallToTxOrCall(call, blockNumber, overrides), nil
-}
-
-func parseResults(
-	decodedCallResult []any, status bool, callOrTxResult any, callOrTx TxOrCall,
-) Result {
-	var result Result
-	if len(decodedCallResult) > 0 {
-		result = Result{
-			Success:  status,
-			Result:   decodedCallResult,
-			Error:    nil,
-			TxOrCall: callOrTx,
-		}
-	} else {
-		result = Result{
-			Success:  status,
-			Result:   callOrTxResult,
-			Error:    nil,
-			TxOrCall: callOrTx,
-		}
-	}
-
-	return result
-}
-
-func decodeAggregateCallsResult(result []any, calls CallsInterface) ([]any, error) {
-	var decodedResult []any
-	for i, res := range result {
-		returnTypes := calls.GetReturnTypes(i)
-		if returnTypes != nil || len(returnTypes) > 0 {
-			r, ok := res.([]byte)
-			var decodedR []any
-			var err error
-			if ok {
-				decodedR, err = abi.Decode(returnTypes, r)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				anyR, ok := res.([]any)
-				if len(anyR) == 1 {
-					innerDecodedResult, err := decodeAggregateCallsResult(anyR, calls)
-					if err != nil {
-						return nil, err
-					}
-					for len(innerDecodedResult) == 1 {
-						inIDR, ok := innerDecodedResult[0].([]any)
-						if !ok {
-							break
-						}
-						innerDecodedResult = inIDR
-					}
-					decodedR = append(decodedR, innerDecodedResult...)
-				} else if ok {
-					rr, ok := anyR[1].([]byte)
-					if ok {
-						decodedR, err = abi.Decode(returnTypes, rr)
-						if err != nil {
-							return nil, err
-						}
-						decodedR = append([]any{anyR[0]}, decodedR...)
-					}
-				}
-			}
-			decodedResult = append(decodedResult, decodedR)
-			continue
-		}
-
-		resAny, ok := res.([]any)
-		if ok {
-			decodedResult = append(decodedResult, resAny)
-		} else {
-			decodedResult = append(decodedResult, res)
-		}
-	}
-
-	return decodedResult, nil
+	return callResults, FromCallToTxOrCall(call, blockNumber, overrides), nil
 }
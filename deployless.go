@@ -8,10 +8,13 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/omnes-tech/abi"
 )
 
+// CallType selects what DEPLOYLESS_MULTICALL_BYTECODE runs. The values are
+// fixed by the compiled contract, which has no aggregate mode: aggregate
+// previews run through SIMULATE_CALL, which executes the calls in order in a
+// single frame, as the deployed contract would within an eth_call.
 type CallType uint8
 
 const (
@@ -31,93 +34,217 @@ type CallsWithRequireSuccess struct {
 	RequireSuccess bool
 }
 
-func deploylessSimulation(calls Calls, client *ethclient.Client, from *common.Address, blockNumber *big.Int, overrides StateOverride) Result {
-	arrayfiedCalls, _, err := calls.ToArray(true, false)
+func deploylessSimulation(ctx context.Context, calls Calls, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride) Result {
+	entries, txOrCall, err := deploylessSimulate(ctx, calls, client, from, blockNumber, overrides)
 	if err != nil {
-		return Result{Success: false, Error: err}
+		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+	}
+
+	callResults, err := newCallResults(entries, calls)
+	if err != nil {
+		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+	}
+
+	return Result{
+		Success:  true,
+		Result:   callResults,
+		TxOrCall: txOrCall,
+	}
+}
+
+// deploylessSimulate runs calls through SIMULATE_CALL, sending their summed value,
+// and returns the decoded (bool,bytes,uint256) outcome of each call.
+func deploylessSimulate(
+	ctx context.Context, calls Calls, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) ([]any, TxOrCall, error) {
+	arrayfiedCalls, msgValue, err := calls.ToArray(true, false)
+	if err != nil {
+		return nil, TxOrCall{}, err
 	}
 
 	_, txOrCall, err := makeDeploylessCall(
+		ctx,
 		arrayfiedCalls,
 		false,
 		SIMULATE_CALL,
 		from,
+		msgValue,
 		client,
 		[]string{"(address,bytes,uint256)[]"},
 		blockNumber,
 		overrides,
 	)
+	entries, err := simulationEntries(err, calls)
+	return entries, txOrCall, err
+}
+
+// deploylessSimulateAt is like deploylessSimulate but runs SIMULATE_CALL as the
+// code of account, placed with a code override, so the calls are made by
+// account itself and their value is taken from its balance.
+func deploylessSimulateAt(
+	ctx context.Context, calls Calls, account common.Address, client Backend, blockNumber *big.Int, overrides StateOverride,
+) ([]any, TxOrCall, error) {
+	arrayfiedCalls, msgValue, err := calls.ToArray(true, false)
 	if err != nil {
-		if strings.Contains(err.Error(), "execution reverted") {
-			encodedRevert, ok := parseRevertData(err)
-			if ok {
-				decodedRevert, err := abi.DecodeWithSignature(
-					"MultiCall__Simulation((bool,bytes,uint256)[])",
-					encodedRevert,
-				)
-				if err != nil {
-					return Result{Success: false, Error: err, TxOrCall: txOrCall}
-				}
-				decodedRevert = decodedRevert[0].([]any)
-
-				for i, result := range decodedRevert {
-					decodedRevert[i].([]any)[1] = common.Bytes2Hex(result.([]any)[1].([]byte))
-				}
-
-				return Result{
-					Success:  true,
-					Result:   decodedRevert,
-					TxOrCall: txOrCall,
-				}
+		return nil, TxOrCall{}, err
+	}
+
+	code, err := deploylessCode(arrayfiedCalls, false, SIMULATE_CALL, []string{"(address,bytes,uint256)[]"})
+	if err != nil {
+		return nil, TxOrCall{}, err
+	}
+
+	simulationOverrides := StateOverride{}
+	simulationOverrides.Add(overrides)
+	simulationOverrides.AddAccount(account, OverrideAccount{Code: hexutil.Bytes(code)})
+
+	_, call, err := readContract(ctx, client, &account, &account, msgValue, nil, blockNumber, simulationOverrides)
+	txOrCall := FromCallToTxOrCall(call, blockNumber, simulationOverrides)
+
+	entries, err := simulationEntries(err, calls)
+	return entries, txOrCall, err
+}
+
+// simulationEntries extracts the outcome of each call from the
+// MultiCall__Simulation revert ending a simulation. err is the error of the
+// simulating eth_call, which always reverts.
+func simulationEntries(err error, calls CallsInterface) ([]any, error) {
+	if err == nil {
+		return nil, ErrNoSimulationResult
+	}
+
+	if strings.Contains(err.Error(), "execution reverted") {
+		encodedRevert, ok := parseRevertData(err)
+		if ok {
+			decodedRevert, err := abi.DecodeWithSignature(
+				"MultiCall__Simulation((bool,bytes,uint256)[])",
+				encodedRevert,
+			)
+			if err != nil {
+				return nil, err
 			}
+
+			return decodedRevert[0].([]any), nil
+		}
+	}
+
+	return nil, decodeMultiCallError(err, calls)
+}
+
+// deploylessDelegateSimulation runs calls through SIMULATE_DELEGATE_CALL in the
+// context of account. The deployless bytecode, followed by its constructor
+// argument, is placed at account with a code override and called from from:
+// the constructor logic then runs as account's code, delegate calling each
+// target against account's storage and balance before reverting with the outcome.
+func deploylessDelegateSimulation(
+	ctx context.Context, calls Calls, account common.Address, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	for i := range calls {
+		if value := calls.GetValue(i); value != nil && value.Sign() > 0 {
+			return Result{Success: false, Error: fmt.Errorf("%w: call %d is a delegate call", ErrSendingValueNotAllowed, i)}
 		}
+	}
+
+	arrayfiedCalls, _, err := calls.ToArray(false, false)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+
+	code, err := deploylessCode(arrayfiedCalls, false, SIMULATE_DELEGATE_CALL, []string{"(address,bytes)[]"})
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+
+	simulationOverrides := StateOverride{}
+	simulationOverrides.Add(overrides)
+	simulationOverrides.AddAccount(account, OverrideAccount{Code: hexutil.Bytes(code)})
+
+	_, call, err := readContract(ctx, client, from, &account, nil, nil, blockNumber, simulationOverrides)
+	txOrCall := FromCallToTxOrCall(call, blockNumber, simulationOverrides)
+
+	entries, err := simulationEntries(err, calls)
+	if err != nil {
+		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+	}
+
+	callResults, err := newCallResults(entries, calls)
+	if err != nil {
 		return Result{Success: false, Error: err, TxOrCall: txOrCall}
 	}
 
-	return Result{Success: false, Error: fmt.Errorf("call did not returned simulation result"), TxOrCall: txOrCall}
+	return Result{Success: true, Result: callResults, TxOrCall: txOrCall}
 }
 
-func deploylessAggregateStatic(calls Calls, client *ethclient.Client, from *common.Address, blockNumber *big.Int, overrides StateOverride) Result {
+// deploylessAggregateCalls previews aggregateCalls and tryAggregateCalls without a
+// deployed contract. The deployless bytecode has no value-carrying aggregate mode,
+// so the calls run through SIMULATE_CALL and the deployed contract's revert rule
+// is applied to the outcome: the first failed call for which requireSuccess
+// holds fails the batch.
+func deploylessAggregateCalls(
+	ctx context.Context, calls Calls, requireSuccess func(i int) bool, client Backend,
+	from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	entries, txOrCall, err := deploylessSimulate(ctx, calls, client, from, blockNumber, overrides)
+	if err != nil {
+		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+	}
+
+	tryEntries := make([]any, len(entries))
+	for i, entry := range entries {
+		fields, ok := entry.([]any)
+		if !ok || len(fields) < 2 {
+			return Result{Success: false, Error: fmt.Errorf("call %d: unexpected simulation result %v", i, entry), TxOrCall: txOrCall}
+		}
+
+		if success, _ := fields[0].(bool); !success && requireSuccess(i) {
+			return Result{
+				Success: false,
+				Error: &CallFailedError{
+					Index:  i,
+					Target: calls[i].Target,
+					Revert: newRevertError(toBytes(fields[1])),
+				},
+				TxOrCall: txOrCall,
+			}
+		}
+		tryEntries[i] = fields[:2]
+	}
+
+	callResults, err := newCallResults(tryEntries, calls)
+	if err != nil {
+		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+	}
+
+	return Result{Success: true, Result: callResults, TxOrCall: txOrCall}
+}
+
+func deploylessAggregateStatic(ctx context.Context, calls Calls, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride) Result {
 	arrayfiedCalls, _, err := calls.ToArray(false, false)
 	if err != nil {
 		return Result{Success: false, Error: err}
 	}
 
 	rawResponse, txOrCall, err := makeDeploylessCall(
+		ctx,
 		arrayfiedCalls,
 		false,
 		STATIC_CALL,
 		from,
+		nil,
 		client,
 		[]string{"(address,bytes)[]"},
 		blockNumber,
 		overrides,
 	)
 	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: txOrCall}
-	}
-
-	resultArgs, err := abi.Decode([]string{"bytes[]"}, common.Hex2Bytes(rawResponse[2:]))
-	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: txOrCall}
-	}
-	resultArgs = resultArgs[0].([]any)
-
-	var result []any
-	for i, call := range calls {
-		result_i, err := abi.Decode(call.ReturnTypes, resultArgs[i].([]byte))
-		if err != nil {
-			return Result{Success: false, Error: err, TxOrCall: txOrCall}
-		}
-
-		result = append(result, result_i)
+		return Result{Success: false, Error: decodeMultiCallError(err, calls), TxOrCall: txOrCall}
 	}
 
-	return Result{Success: true, Result: result, TxOrCall: txOrCall}
+	return deploylessCallResults(rawResponse, []string{"bytes[]"}, calls, txOrCall)
 }
 
 func deploylessTryAggregateStatic(
-	calls Calls, requireSuccess bool, client *ethclient.Client, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+	ctx context.Context, calls Calls, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
 ) Result {
 	arrayfiedCalls, _, err := calls.ToArray(false, false)
 	if err != nil {
@@ -125,40 +252,26 @@ func deploylessTryAggregateStatic(
 	}
 
 	rawResponse, txOrCall, err := makeDeploylessCall(
+		ctx,
 		arrayfiedCalls,
 		requireSuccess,
 		TRY_STATIC_CALL,
 		from,
+		nil,
 		client,
 		[]string{"(address,bytes)[]", "bool"},
 		blockNumber,
 		overrides,
 	)
 	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: txOrCall}
-	}
-
-	resultArgs, err := abi.Decode([]string{"(bool,bytes)[]"}, common.Hex2Bytes(rawResponse[2:]))
-	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+		return Result{Success: false, Error: decodeMultiCallError(err, calls), TxOrCall: txOrCall}
 	}
-	resultArgs = resultArgs[0].([]any)
 
-	var result []any
-	for i, call := range calls {
-		resultArgs[i].([]any)[1], err = abi.Decode(call.ReturnTypes, resultArgs[i].([]any)[1].([]byte))
-		if err != nil {
-			return Result{Success: false, Error: err, TxOrCall: txOrCall}
-		}
-
-		result = append(result, resultArgs[i])
-	}
-
-	return Result{Success: true, Result: result, TxOrCall: txOrCall}
+	return deploylessCallResults(rawResponse, []string{"(bool,bytes)[]"}, calls, txOrCall)
 }
 
 func deploylessTryAggregateStatic3(
-	calls CallsWithFailure, client *ethclient.Client, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+	ctx context.Context, calls CallsWithFailure, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
 ) Result {
 	arrayfiedCalls, _, err := calls.ToArray(false, false)
 	if err != nil {
@@ -166,47 +279,49 @@ func deploylessTryAggregateStatic3(
 	}
 
 	rawResponse, txOrCall, err := makeDeploylessCall(
+		ctx,
 		arrayfiedCalls,
 		false,
 		TRY_STATIC_CALL2,
 		from,
+		nil,
 		client,
 		[]string{"(address,bytes,bool)[]"},
 		blockNumber,
 		overrides,
 	)
 	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+		return Result{Success: false, Error: decodeMultiCallError(err, calls), TxOrCall: txOrCall}
 	}
 
-	resultArgs, err := abi.Decode([]string{"(bool,bytes)[]"}, common.Hex2Bytes(rawResponse[2:]))
+	return deploylessCallResults(rawResponse, []string{"(bool,bytes)[]"}, calls, txOrCall)
+}
+
+// deploylessCallResults decodes the single array returned by the deployless
+// aggregate modes into per-call results.
+func deploylessCallResults(rawResponse string, returnTypes []string, calls CallsInterface, txOrCall TxOrCall) Result {
+	resultArgs, err := safeDecode(returnTypes, common.FromHex(rawResponse))
 	if err != nil {
 		return Result{Success: false, Error: err, TxOrCall: txOrCall}
 	}
-	resultArgs = resultArgs[0].([]any)
 
-	var result []any
-	for i, call := range calls {
-		resultArgs[i].([]any)[1], err = abi.Decode(call.ReturnTypes, resultArgs[i].([]any)[1].([]byte))
-		if err != nil {
-			return Result{Success: false, Error: err, TxOrCall: txOrCall}
-		}
-
-		result = append(result, resultArgs[i])
+	callResults, err := newCallResults(resultArgs[0].([]any), calls)
+	if err != nil {
+		return Result{Success: false, Error: err, TxOrCall: txOrCall}
 	}
 
-	return Result{Success: true, Result: result, TxOrCall: txOrCall}
+	return Result{Success: true, Result: callResults, TxOrCall: txOrCall}
 }
 
 func deploylessGetCodeLengths(
-	addresses []*common.Address, client *ethclient.Client, blockNumber *big.Int,
+	ctx context.Context, addresses []*common.Address, client Backend, blockNumber *big.Int,
 ) Result {
 
 	rawResponse, txOrCall, err := makeDeploylessCall(
-		toAnyArray(addresses), false, CODE_LENGTH, nil, client, []string{"address[]"}, blockNumber, nil,
+		ctx, toAnyArray(addresses), false, CODE_LENGTH, nil, nil, client, []string{"address[]"}, blockNumber, nil,
 	)
 	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+		return Result{Success: false, Error: decodeMultiCallError(err, nil), TxOrCall: txOrCall}
 	}
 
 	resultArgs, err := abi.Decode([]string{"uint256[]"}, common.Hex2Bytes(rawResponse[2:]))
@@ -219,14 +334,14 @@ func deploylessGetCodeLengths(
 }
 
 func deploylessGetBalances(
-	addresses []*common.Address, client *ethclient.Client, blockNumber *big.Int,
+	ctx context.Context, addresses []*common.Address, client Backend, blockNumber *big.Int,
 ) Result {
 
 	rawResponse, txOrCall, err := makeDeploylessCall(
-		toAnyArray(addresses), false, BALANCES, nil, client, []string{"address[]"}, blockNumber, nil,
+		ctx, toAnyArray(addresses), false, BALANCES, nil, nil, client, []string{"address[]"}, blockNumber, nil,
 	)
 	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+		return Result{Success: false, Error: decodeMultiCallError(err, nil), TxOrCall: txOrCall}
 	}
 
 	resultArgs, err := abi.Decode([]string{"uint256[]"}, common.Hex2Bytes(rawResponse[2:]))
@@ -239,14 +354,14 @@ func deploylessGetBalances(
 }
 
 func deploylessGetAddressesData(
-	addresses []*common.Address, client *ethclient.Client, blockNumber *big.Int,
+	ctx context.Context, addresses []*common.Address, client Backend, blockNumber *big.Int,
 ) Result {
 
 	rawResponse, txOrCall, err := makeDeploylessCall(
-		toAnyArray(addresses), false, ADDRESSES_DATA, nil, client, []string{"address[]"}, blockNumber, nil,
+		ctx, toAnyArray(addresses), false, ADDRESSES_DATA, nil, nil, client, []string{"address[]"}, blockNumber, nil,
 	)
 	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+		return Result{Success: false, Error: decodeMultiCallError(err, nil), TxOrCall: txOrCall}
 	}
 
 	resultArgs, err := abi.Decode([]string{"uint256[]", "uint256[]"}, common.Hex2Bytes(rawResponse[2:]))
@@ -262,27 +377,17 @@ func deploylessGetAddressesData(
 	return Result{Success: true, Result: result, TxOrCall: txOrCall}
 }
 
-func deploylessGetChainData(client *ethclient.Client, blockNumber *big.Int) Result {
+func deploylessGetChainData(ctx context.Context, client Backend, blockNumber *big.Int) Result {
 
 	rawResponse, txOrCall, err := makeDeploylessCall(
-		nil, false, CHAIN_DATA, nil, client, nil, blockNumber, nil,
+		ctx, nil, false, CHAIN_DATA, nil, nil, client, nil, blockNumber, nil,
 	)
 	if err != nil {
-		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+		return Result{Success: false, Error: decodeMultiCallError(err, nil), TxOrCall: txOrCall}
 	}
 
 	resultArgs, err := abi.Decode(
-		[]string{
-			"uint256",
-			"uint256",
-			"bytes32",
-			"uint256",
-			"address",
-			"uint256",
-			"uint256",
-			"uint256",
-			"uint256",
-		},
+		chainDataTypes,
 		common.Hex2Bytes(rawResponse[2:]),
 	)
 	if err != nil {
@@ -293,32 +398,14 @@ func deploylessGetChainData(client *ethclient.Client, blockNumber *big.Int) Resu
 }
 
 func makeDeploylessCall(
-	params []any, requireSuccess bool, callType CallType,
-	from *common.Address, client *ethclient.Client, typeStrs []string, blockNumber *big.Int, overrides StateOverride,
+	ctx context.Context, params []any, requireSuccess bool, callType CallType,
+	from *common.Address, value *big.Int, client Backend, typeStrs []string, blockNumber *big.Int, overrides StateOverride,
 ) (string, TxOrCall, error) {
-	var encoded []byte
-	var err error
-	if callType == TRY_STATIC_CALL {
-		encoded, err = abi.Encode(typeStrs, params, requireSuccess)
-	} else if typeStrs != nil && params != nil {
-		encoded, err = abi.Encode(typeStrs, params)
-	}
-	if err != nil {
-		return "", TxOrCall{}, err
-	}
-
-	encodedParams, err := abi.EncodePacked([]string{"uint8", "bytes"}, big.NewInt(int64(callType)), encoded)
-	if err != nil {
-		return "", TxOrCall{}, err
-	}
-
-	encodedParamsToDeploy, err := abi.Encode([]string{"bytes"}, encodedParams)
+	data, err := deploylessCode(params, requireSuccess, callType, typeStrs)
 	if err != nil {
 		return "", TxOrCall{}, err
 	}
 
-	data := DEPLOYLESS_MULTICALL_BYTECODE + common.Bytes2Hex(encodedParamsToDeploy)
-
 	var blockIdentifier string
 	if blockNumber != nil {
 		blockIdentifier = hexutil.EncodeBig(blockNumber)
@@ -339,22 +426,52 @@ func makeDeploylessCall(
 			Data: hexutil.Bytes(data),
 		}
 	}
+	if value != nil && value.Sign() > 0 {
+		call.Value = (*hexutil.Big)(value)
+	}
 
 	var rawResponse string
-	err = client.Client().CallContext(context.Background(), &rawResponse, "eth_call", call, blockIdentifier, overrides)
+	err = client.CallContext(ctx, &rawResponse, "eth_call", call, blockIdentifier, overrides)
 	if err != nil {
-		return rawResponse, TxOrCall{}, fmt.Errorf("error making deployless call: %w, with data: %s", err, data)
+		return rawResponse, TxOrCall{}, fmt.Errorf("error making deployless call: %w, with data: %s", err, hexutil.Encode(data))
 	}
 
 	if blockNumber == nil {
-		blockNumberUint64, err := client.BlockNumber(context.Background())
+		blockNumberUint64, err := client.BlockNumber(ctx)
 		if err != nil {
 			return rawResponse, TxOrCall{}, fmt.Errorf("error getting block number: %w", err)
 		}
 		blockNumber = big.NewInt(int64(blockNumberUint64))
 	}
 
-	return rawResponse, TxOrCall{To: nil, Data: common.Hex2Bytes(data), BlockNumber: blockNumber}, nil
+	return rawResponse, TxOrCall{To: nil, Value: value, Data: data, BlockNumber: blockNumber}, nil
+}
+
+// deploylessCode returns the deployless bytecode followed by its constructor
+// argument, which selects callType and carries params.
+func deploylessCode(params []any, requireSuccess bool, callType CallType, typeStrs []string) ([]byte, error) {
+	var encoded []byte
+	var err error
+	if callType == TRY_STATIC_CALL {
+		encoded, err = abi.Encode(typeStrs, params, requireSuccess)
+	} else if typeStrs != nil && params != nil {
+		encoded, err = abi.Encode(typeStrs, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	encodedParams, err := abi.EncodePacked([]string{"uint8", "bytes"}, big.NewInt(int64(callType)), encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedParamsToDeploy, err := abi.Encode([]string{"bytes"}, encodedParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(common.FromHex(DEPLOYLESS_MULTICALL_BYTECODE), encodedParamsToDeploy...), nil
 }
 
 func toAnyArray(addresses []*common.Address) []any {
@@ -0,0 +1,153 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/omnes-tech/abi"
+)
+
+func TestDeploylessAggregateCalls(t *testing.T) {
+	okData, _ := abi.Encode([]string{"uint256"}, big.NewInt(7))
+	revertData, _ := abi.EncodeWithSignature("Error(string)", "nope")
+	simulation := encodeRevert(t, "MultiCall__Simulation((bool,bytes,uint256)[])", []any{
+		[]any{true, okData, big.NewInt(30000)},
+		[]any{false, revertData, big.NewInt(25000)},
+	})
+
+	var sent CallArgs
+	backend := &fakeBackend{
+		blockNumber: 1,
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			sent = args[0].(CallArgs)
+			return &revertErr{simulation}
+		},
+	}
+	m, err := NewMultiCall(backend, nil, WithDeploylessMode())
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCalls(
+		[]common.Address{ZERO_ADDRESS, target},
+		[]string{"a()", "b()"},
+		nil,
+		nil,
+		[][]string{{"uint256"}, nil},
+		[]*big.Int{big.NewInt(1), big.NewInt(2)},
+	)
+
+	t.Run("aggregate fails on the reverted call", func(t *testing.T) {
+		result := m.AggregateCalls(calls, backend, nil, nil, true, nil)
+
+		var callFailed *CallFailedError
+		if !errors.As(result.Error, &callFailed) || callFailed.Index != 1 || callFailed.Target != target {
+			t.Fatalf("Error = %v, want call 1 to fail", result.Error)
+		}
+		if callFailed.Revert == nil || callFailed.Revert.Reason != "nope" {
+			t.Fatalf("Revert = %v, want nope", callFailed.Revert)
+		}
+		if !bytes.HasPrefix(sent.Data, common.FromHex(DEPLOYLESS_MULTICALL_BYTECODE)) {
+			t.Fatalf("call data does not start with the deployless bytecode")
+		}
+		if sent.Value == nil || (*big.Int)(sent.Value).Int64() != 3 {
+			t.Fatalf("Value = %v, want 3", sent.Value)
+		}
+	})
+
+	t.Run("try aggregate returns every outcome", func(t *testing.T) {
+		result := m.TryAggregateCalls(calls, false, backend, nil, nil, true, nil)
+		if !result.Success {
+			t.Fatalf("TryAggregateCalls: %v", result.Error)
+		}
+
+		callResults := result.CallResults()
+		if len(callResults) != 2 || !callResults[0].Success || callResults[1].Success {
+			t.Fatalf("CallResults = %v", callResults)
+		}
+		if callResults[0].GasUsed != nil || callResults[0].Decoded[0].(*big.Int).Int64() != 7 {
+			t.Fatalf("call 0 = %v, want the deployed try shape", callResults[0])
+		}
+		if callResults[1].Revert == nil || callResults[1].Revert.Reason != "nope" {
+			t.Fatalf("call 1 = %v", callResults[1])
+		}
+	})
+
+	t.Run("try aggregate 3 honors per call requirements", func(t *testing.T) {
+		withFailure := []CallWithFailure{
+			{Call: calls[0], RequireSuccess: true},
+			{Call: calls[1], RequireSuccess: true},
+		}
+		if result := m.TryAggregateCalls3(withFailure, backend, nil, nil, true, nil); !errors.Is(result.Error, ErrCallFailed) {
+			t.Fatalf("Error = %v, want ErrCallFailed", result.Error)
+		}
+
+		withFailure[1].RequireSuccess = false
+		if result := m.TryAggregateCalls3(withFailure, backend, nil, nil, true, nil); !result.Success {
+			t.Fatalf("TryAggregateCalls3: %v", result.Error)
+		}
+	})
+
+	t.Run("transactions still need a deployed contract", func(t *testing.T) {
+		signer := SignerInterface(nil)
+		m := &MultiCall{Signer: &signer}
+		if result := m.AggregateCalls(calls, backend, nil, nil, false, nil); !errors.Is(result.Error, ErrNoMulticallContract) {
+			t.Fatalf("Error = %v, want ErrNoMulticallContract", result.Error)
+		}
+	})
+}
+
+func TestSimulateDelegateCall(t *testing.T) {
+	okData, _ := abi.Encode([]string{"uint256"}, big.NewInt(7))
+	simulation := encodeRevert(t, "MultiCall__Simulation((bool,bytes,uint256)[])", []any{
+		[]any{true, okData, big.NewInt(30000)},
+	})
+
+	wallet := common.HexToAddress("0x000000000000000000000000000000000000a11c")
+	var sent CallArgs
+	var sentOverrides StateOverride
+	backend := &fakeBackend{
+		blockNumber: 1,
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			sent = args[0].(CallArgs)
+			sentOverrides = args[2].(StateOverride)
+			return &revertErr{simulation}
+		},
+	}
+	m, err := NewMultiCall(backend, nil, WithDeploylessMode())
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+
+	module := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCalls([]common.Address{module}, []string{"a()"}, nil, nil, [][]string{{"uint256"}}, nil)
+
+	result := m.SimulateDelegateCall(calls, wallet, backend, nil, nil, StateOverride{wallet: {Balance: (*hexutil.Big)(big.NewInt(1))}})
+	if result.Error != nil {
+		t.Fatalf("Error = %v", result.Error)
+	}
+	if callResults := result.CallResults(); len(callResults) != 1 || callResults[0].GasUsed.Int64() != 30000 ||
+		callResults[0].Decoded[0].(*big.Int).Int64() != 7 {
+		t.Fatalf("CallResults = %v", callResults)
+	}
+
+	if *sent.To != wallet {
+		t.Fatalf("To = %s, want the wallet", sent.To)
+	}
+	override := sentOverrides[wallet]
+	wantCode, _ := deploylessCode([]any{[]any{&module, abi.EncodeSignature("a()")}}, false, SIMULATE_DELEGATE_CALL, []string{"(address,bytes)[]"})
+	if !bytes.Equal(override.Code, wantCode) || override.Balance == nil {
+		t.Fatalf("wallet override = %+v, want the simulator code on top of the caller's overrides", override)
+	}
+
+	valued := NewCalls([]common.Address{module}, []string{"a()"}, nil, nil, nil, []*big.Int{big.NewInt(1)})
+	if result := m.SimulateDelegateCall(valued, wallet, backend, nil, nil, nil); !errors.Is(result.Error, ErrSendingValueNotAllowed) {
+		t.Fatalf("Error = %v, want ErrSendingValueNotAllowed", result.Error)
+	}
+}
@@ -0,0 +1,105 @@
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Deployment records the block a multicall contract was deployed at on a chain.
+// Calls for earlier blocks are served without the contract.
+type Deployment struct {
+	ChainID uint64
+	Address common.Address
+	// Block is the first block holding the contract code.
+	Block uint64
+}
+
+type deploymentKey struct {
+	chainID uint64
+	address common.Address
+}
+
+var (
+	deploymentsMu sync.RWMutex
+	deployments   = map[deploymentKey]Deployment{}
+)
+
+// OMNES_DEPLOYMENT_BLOCKS maps chain IDs to the block OMNES_MULTICALL_ADDRESS was
+// deployed at, registered by default.
+var OMNES_DEPLOYMENT_BLOCKS = map[uint64]uint64{}
+
+func init() {
+	for chainID, block := range OMNES_DEPLOYMENT_BLOCKS {
+		RegisterDeployment(Deployment{ChainID: chainID, Address: OMNES_MULTICALL_ADDRESS, Block: block})
+	}
+	for chainID, block := range map[uint64]uint64{
+		1:        14353601, // ethereum
+		10:       4286263,  // optimism
+		56:       15921452, // bsc
+		100:      21022491, // gnosis
+		137:      25770160, // polygon
+		8453:     5022,     // base
+		42161:    7654707,  // arbitrum one
+		43114:    11907934, // avalanche c-chain
+		11155111: 751532,   // sepolia
+	} {
+		RegisterDeployment(Deployment{ChainID: chainID, Address: MULTICALL3_ADDRESS, Block: block})
+	}
+}
+
+// RegisterDeployment adds or replaces the deployment of a multicall contract,
+// e.g. on a private network.
+func RegisterDeployment(deployment Deployment) {
+	deploymentsMu.Lock()
+	defer deploymentsMu.Unlock()
+
+	deployments[deploymentKey{deployment.ChainID, deployment.Address}] = deployment
+}
+
+func LookupDeployment(chainID uint64, address common.Address) (Deployment, bool) {
+	deploymentsMu.RLock()
+	defer deploymentsMu.RUnlock()
+
+	deployment, ok := deployments[deploymentKey{chainID, address}]
+	return deployment, ok
+}
+
+// deploymentBlock returns the block address was deployed at on the chain of
+// client, or nil when it is not registered. The chain ID is only fetched when
+// address has a deployment on some chain.
+func deploymentBlock(ctx context.Context, client Backend, address common.Address) (*big.Int, error) {
+	deploymentsMu.RLock()
+	registered := false
+	for key := range deployments {
+		if key.address == address {
+			registered = true
+			break
+		}
+	}
+	deploymentsMu.RUnlock()
+	if !registered {
+		return nil, nil
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting chain id: %w", err)
+	}
+
+	deployment, ok := LookupDeployment(chainID.Uint64(), address)
+	if !ok {
+		return nil, nil
+	}
+
+	return new(big.Int).SetUint64(deployment.Block), nil
+}
+
+// deployedAt reports whether a contract deployed at deployment exists at
+// blockNumber. Unknown deployments and the latest block always do.
+func deployedAt(deployment *big.Int, blockNumber *big.Int) bool {
+	return deployment == nil || blockNumber == nil || blockNumber.Cmp(deployment) >= 0
+}
@@ -0,0 +1,136 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestRegisterDeployment(t *testing.T) {
+	if deployment, ok := LookupDeployment(1, MULTICALL3_ADDRESS); !ok || deployment.Block != 14353601 {
+		t.Fatalf("LookupDeployment(1, multicall3) = %+v, %v", deployment, ok)
+	}
+
+	RegisterDeployment(Deployment{ChainID: 1337, Address: OMNES_MULTICALL_ADDRESS, Block: 100})
+	t.Cleanup(func() {
+		deploymentsMu.Lock()
+		delete(deployments, deploymentKey{1337, OMNES_MULTICALL_ADDRESS})
+		deploymentsMu.Unlock()
+	})
+
+	var targets []*common.Address
+	backend := &fakeBackend{
+		chainID: big.NewInt(1337),
+		code:    map[common.Address][]byte{OMNES_MULTICALL_ADDRESS: {0x60, 0x80}},
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			targets = append(targets, args[0].(CallArgs).To)
+			return errNotImplemented
+		},
+	}
+
+	m, err := NewMultiCall(backend, nil, WithDeployedMode())
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+	calls := NewCalls([]common.Address{ZERO_ADDRESS}, []string{"a()"}, nil, nil, nil, nil)
+
+	m.AggregateStatic(calls, backend, nil, big.NewInt(99), nil)
+	if len(targets) != 1 || targets[0] != nil {
+		t.Fatalf("call before deployment sent to %v, want deployless", targets)
+	}
+
+	m.AggregateStatic(calls, backend, nil, big.NewInt(100), nil)
+	if len(targets) != 2 || targets[1] == nil || *targets[1] != OMNES_MULTICALL_ADDRESS {
+		t.Fatalf("call after deployment sent to %v, want the contract", targets[1:])
+	}
+
+	m3, err := NewMultiCall3(&fakeBackend{code: map[common.Address][]byte{MULTICALL3_ADDRESS: {0x60, 0x80}}}, nil)
+	if err != nil {
+		t.Fatalf("NewMultiCall3: %v", err)
+	}
+	if result := m3.GetBlockNumber(backend, big.NewInt(14353600)); !errors.Is(result.Error, ErrNoMulticallContract) {
+		t.Fatalf("Error = %v, want ErrNoMulticallContract", result.Error)
+	}
+}
+
+func TestBuiltinDeployments(t *testing.T) {
+	var targets []*common.Address
+	backend := &fakeBackend{
+		chainID: big.NewInt(1),
+		code:    map[common.Address][]byte{MULTICALL3_ADDRESS: {0x60, 0x80}},
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			targets = append(targets, args[0].(CallArgs).To)
+			return nil
+		},
+	}
+
+	// the Multicall3 deployment on ethereum comes from the built-in registry
+	m, err := NewMultiCall(backend, nil, WithDetector(NewDetector()))
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+	calls := NewCalls([]common.Address{ZERO_ADDRESS}, []string{"a()"}, nil, nil, nil, nil)
+
+	targets = nil
+	m.AggregateStatic(calls, backend, nil, big.NewInt(14353600), nil)
+	if len(targets) != 1 || targets[0] != nil {
+		t.Fatalf("call before the Multicall3 deployment sent to %v, want deployless", targets)
+	}
+
+	targets = nil
+	m.AggregateStatic(calls, backend, nil, big.NewInt(14353601), nil)
+	if len(targets) != 1 || targets[0] == nil || *targets[0] != MULTICALL3_ADDRESS {
+		t.Fatalf("call after the Multicall3 deployment sent to %v, want Multicall3", targets)
+	}
+}
+
+func TestOmnesDeployments(t *testing.T) {
+	for chainID, block := range OMNES_DEPLOYMENT_BLOCKS {
+		if deployment, ok := LookupDeployment(chainID, OMNES_MULTICALL_ADDRESS); !ok || deployment.Block != block {
+			t.Fatalf("LookupDeployment(%d, omnes) = %+v, %v; want block %d", chainID, deployment, ok, block)
+		}
+	}
+
+	// a deployment registered like the built-in ones routes historical blocks
+	// without a detector
+	RegisterDeployment(Deployment{ChainID: 1337, Address: OMNES_MULTICALL_ADDRESS, Block: 100})
+	t.Cleanup(func() {
+		deploymentsMu.Lock()
+		delete(deployments, deploymentKey{1337, OMNES_MULTICALL_ADDRESS})
+		deploymentsMu.Unlock()
+	})
+	registerCode(t, []byte{0x60, 0x80}, "v1")
+
+	var targets []*common.Address
+	backend := &fakeBackend{
+		chainID: big.NewInt(1337),
+		code:    map[common.Address][]byte{OMNES_MULTICALL_ADDRESS: {0x60, 0x80}},
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			targets = append(targets, args[0].(CallArgs).To)
+			return nil
+		},
+	}
+
+	m, err := NewMultiCall(backend, nil)
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+	if !m.IsDeployed() {
+		t.Fatalf("ContractAddress = %v, want the registered code to be trusted", m.ContractAddress)
+	}
+	calls := NewCalls([]common.Address{ZERO_ADDRESS}, []string{"a()"}, nil, nil, nil, nil)
+
+	m.AggregateStatic(calls, backend, nil, big.NewInt(99), nil)
+	if len(targets) != 1 || targets[0] != nil {
+		t.Fatalf("call before the deployment sent to %v, want deployless", targets)
+	}
+
+	targets = nil
+	m.AggregateStatic(calls, backend, nil, big.NewInt(100), nil)
+	if len(targets) != 1 || targets[0] == nil || *targets[0] != OMNES_MULTICALL_ADDRESS {
+		t.Fatalf("call after the deployment sent to %v, want the contract", targets)
+	}
+}
@@ -0,0 +1,238 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Strategy is a way of serving an operation on a chain.
+type Strategy uint8
+
+const (
+	StrategyOmnes Strategy = iota
+	StrategyDeployless
+	StrategyMultiCall3
+	StrategyMultiCall2
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case StrategyOmnes:
+		return "omnes"
+	case StrategyDeployless:
+		return "deployless"
+	case StrategyMultiCall3:
+		return "multicall3"
+	case StrategyMultiCall2:
+		return "multicall2"
+	}
+	return fmt.Sprintf("Strategy(%d)", uint8(s))
+}
+
+// Operation groups MultiCall methods by what they need from the chain.
+type Operation uint8
+
+const (
+	// OperationWrite sends aggregated calls in a transaction.
+	OperationWrite Operation = iota
+	// OperationPreview runs aggregated calls as an eth_call (isCall set).
+	OperationPreview
+	// OperationStatic covers AggregateStatic, TryAggregateStatic and TryAggregateStatic3.
+	OperationStatic
+	OperationSimulate
+	OperationBalances
+	// OperationAccountData covers CodeLengths and AddressesData.
+	OperationAccountData
+	OperationChainData
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OperationWrite:
+		return "write"
+	case OperationPreview:
+		return "preview"
+	case OperationStatic:
+		return "static"
+	case OperationSimulate:
+		return "simulate"
+	case OperationBalances:
+		return "balances"
+	case OperationAccountData:
+		return "account data"
+	case OperationChainData:
+		return "chain data"
+	}
+	return fmt.Sprintf("Operation(%d)", uint8(o))
+}
+
+// Capabilities records which multicall flavors a chain offers.
+type Capabilities struct {
+	ChainID *big.Int
+	// Omnes, Multicall3 and Multicall2 report code at OMNES_MULTICALL_ADDRESS,
+	// MULTICALL3_ADDRESS and MULTICALL2_ADDRESS, the Omnes code having a known
+	// runtime code hash.
+	Omnes      bool
+	Multicall3 bool
+	Multicall2 bool
+	// Deployless reports that the node runs eth_calls without a to address.
+	Deployless     bool
+	StateOverrides bool
+	// MaxCalldataBytes is the largest probed eth_call payload the node accepted,
+	// 0 when none was. Static and simulation batches are chunked to fit in it.
+	MaxCalldataBytes int
+}
+
+// Strategy picks the best way to serve op: the Omnes contract when deployed,
+// then a deployed Multicall3 or Multicall2 where they implement op, then
+// deployless calls.
+func (c Capabilities) Strategy(op Operation) (Strategy, error) {
+	if c.Omnes {
+		return StrategyOmnes, nil
+	}
+
+	switch op {
+	case OperationStatic:
+		if c.Multicall3 {
+			return StrategyMultiCall3, nil
+		}
+		if c.Multicall2 {
+			return StrategyMultiCall2, nil
+		}
+		if c.Deployless {
+			return StrategyDeployless, nil
+		}
+	case OperationPreview, OperationBalances:
+		if c.Multicall3 {
+			return StrategyMultiCall3, nil
+		}
+		if c.Deployless {
+			return StrategyDeployless, nil
+		}
+	case OperationWrite:
+		if c.Multicall3 {
+			return StrategyMultiCall3, nil
+		}
+	case OperationSimulate, OperationAccountData, OperationChainData:
+		if c.Deployless {
+			return StrategyDeployless, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: no way to serve %s calls on chain %v", ErrNoMulticallContract, op, c.ChainID)
+}
+
+// probeSizes are the eth_call payloads tried, largest first, to find MaxCalldataBytes.
+var probeSizes = []int{512 * 1024, 128 * 1024, 32 * 1024}
+
+var (
+	identityPrecompile = common.BytesToAddress([]byte{0x04})
+	overrideProbe      = common.HexToAddress("0x00000000000000000000000000000000000c0de5")
+	// returns 42 as a 32 bytes word
+	overrideProbeCode = common.FromHex("0x602a60005260206000f3")
+)
+
+// Detector probes chains for their multicall capabilities, caching the outcome
+// per chain ID. It is safe for concurrent use.
+type Detector struct {
+	mu    sync.Mutex
+	cache map[string]Capabilities
+}
+
+func NewDetector() *Detector {
+	return &Detector{cache: make(map[string]Capabilities)}
+}
+
+// Detect returns the capabilities of the chain client is connected to, probing
+// it on first use.
+func (d *Detector) Detect(ctx context.Context, client Backend) (Capabilities, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("error getting chain id: %w", err)
+	}
+
+	d.mu.Lock()
+	capabilities, ok := d.cache[chainID.String()]
+	d.mu.Unlock()
+	if ok {
+		return capabilities, nil
+	}
+
+	capabilities, err = probe(ctx, client, chainID)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	d.mu.Lock()
+	d.cache[chainID.String()] = capabilities
+	d.mu.Unlock()
+
+	return capabilities, nil
+}
+
+// Forget drops the cached capabilities of chainID, so the next Detect probes again.
+func (d *Detector) Forget(chainID *big.Int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.cache, chainID.String())
+}
+
+// probe checks every capability. Only transport failures and cancellation are
+// reported as errors; a rejected probe call means the capability is missing.
+func probe(ctx context.Context, client Backend, chainID *big.Int) (Capabilities, error) {
+	capabilities := Capabilities{ChainID: chainID}
+
+	code, err := client.CodeAt(ctx, OMNES_MULTICALL_ADDRESS, nil)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("error getting bytecode: %w", err)
+	}
+	_, capabilities.Omnes = trustedCode(code)
+
+	for _, contract := range []struct {
+		address common.Address
+		found   *bool
+	}{
+		{MULTICALL3_ADDRESS, &capabilities.Multicall3},
+		{MULTICALL2_ADDRESS, &capabilities.Multicall2},
+	} {
+		code, err := client.CodeAt(ctx, contract.address, nil)
+		if err != nil {
+			return Capabilities{}, fmt.Errorf("error getting bytecode: %w", err)
+		}
+		*contract.found = len(code) > 0
+	}
+
+	_, _, err = makeDeploylessCall(ctx, nil, false, CHAIN_DATA, nil, nil, client, nil, nil, nil)
+	if ctx.Err() != nil {
+		return Capabilities{}, ctx.Err()
+	}
+	capabilities.Deployless = err == nil
+
+	overrides := StateOverride{overrideProbe: {Code: hexutil.Bytes(overrideProbeCode)}}
+	result, _, err := readContract(ctx, client, nil, &overrideProbe, nil, nil, nil, overrides)
+	if ctx.Err() != nil {
+		return Capabilities{}, ctx.Err()
+	}
+	capabilities.StateOverrides = err == nil && bytes.Equal(result, common.LeftPadBytes([]byte{42}, 32))
+
+	for _, size := range probeSizes {
+		payload := make([]byte, size)
+		_, _, err := readContract(ctx, client, nil, &identityPrecompile, nil, payload, nil, nil)
+		if ctx.Err() != nil {
+			return Capabilities{}, ctx.Err()
+		}
+		if err == nil {
+			capabilities.MaxCalldataBytes = size
+			break
+		}
+	}
+
+	return capabilities, nil
+}
@@ -0,0 +1,162 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/omnes-tech/abi"
+)
+
+// multicall3OnlyBackend is a chain with Multicall3 deployed, a node rejecting
+// deployless calls and payloads over 128KiB, and state overrides supported.
+func multicall3OnlyBackend(t *testing.T, aggregateOutput []byte) (*fakeBackend, *[]CallArgs) {
+	t.Helper()
+
+	var calls []CallArgs
+	backend := &fakeBackend{
+		blockNumber: 10,
+		chainID:     big.NewInt(8453),
+		code:        map[common.Address][]byte{MULTICALL3_ADDRESS: {0x60, 0x80}},
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			call := args[0].(CallArgs)
+			calls = append(calls, call)
+
+			switch {
+			case call.To == nil:
+				return errors.New("contract creation not allowed")
+			case *call.To == overrideProbe:
+				overrides := args[2].(StateOverride)
+				if !bytes.Equal(overrides[overrideProbe].Code, overrideProbeCode) {
+					return nil
+				}
+				*result.(*hexutil.Bytes) = common.LeftPadBytes([]byte{42}, 32)
+			case *call.To == identityPrecompile:
+				if len(call.Data) > 128*1024 {
+					return errors.New("request too large")
+				}
+				*result.(*hexutil.Bytes) = call.Data
+			case *call.To == MULTICALL3_ADDRESS:
+				*result.(*hexutil.Bytes) = aggregateOutput
+			}
+			return nil
+		},
+	}
+
+	return backend, &calls
+}
+
+func TestDetector(t *testing.T) {
+	backend, calls := multicall3OnlyBackend(t, nil)
+	detector := NewDetector()
+
+	capabilities, err := detector.Detect(context.Background(), backend)
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	want := Capabilities{
+		ChainID:          big.NewInt(8453),
+		Multicall3:       true,
+		StateOverrides:   true,
+		MaxCalldataBytes: 128 * 1024,
+	}
+	if capabilities.ChainID.Cmp(want.ChainID) != 0 || capabilities.Omnes || !capabilities.Multicall3 ||
+		capabilities.Multicall2 || capabilities.Deployless || !capabilities.StateOverrides ||
+		capabilities.MaxCalldataBytes != want.MaxCalldataBytes {
+		t.Fatalf("Capabilities = %+v, want %+v", capabilities, want)
+	}
+
+	probes := len(*calls)
+	if _, err := detector.Detect(context.Background(), backend); err != nil || len(*calls) != probes {
+		t.Fatalf("second Detect issued %d calls, err = %v; want cached", len(*calls)-probes, err)
+	}
+
+	detector.Forget(big.NewInt(8453))
+	if _, err := detector.Detect(context.Background(), backend); err != nil || len(*calls) == probes {
+		t.Fatalf("Detect after Forget did not probe again, err = %v", err)
+	}
+}
+
+func TestCapabilities_Strategy(t *testing.T) {
+	tests := []struct {
+		capabilities Capabilities
+		op           Operation
+		want         Strategy
+		wantErr      bool
+	}{
+		{Capabilities{Omnes: true}, OperationSimulate, StrategyOmnes, false},
+		{Capabilities{Multicall3: true, Deployless: true}, OperationStatic, StrategyMultiCall3, false},
+		{Capabilities{Multicall2: true, Deployless: true}, OperationStatic, StrategyMultiCall2, false},
+		{Capabilities{Multicall2: true, Deployless: true}, OperationPreview, StrategyDeployless, false},
+		{Capabilities{Multicall3: true}, OperationWrite, StrategyMultiCall3, false},
+		{Capabilities{Deployless: true}, OperationWrite, 0, true},
+		{Capabilities{Multicall3: true}, OperationSimulate, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.capabilities.Strategy(tt.op)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%+v.Strategy(%s) = %s, %v; want %s", tt.capabilities, tt.op, got, err, tt.want)
+		}
+		if tt.wantErr && !errors.Is(err, ErrNoMulticallContract) {
+			t.Errorf("err = %v, want ErrNoMulticallContract", err)
+		}
+	}
+}
+
+func TestNewMultiCall_Detector(t *testing.T) {
+	okData, _ := abi.Encode([]string{"uint256"}, big.NewInt(7))
+	aggregateOutput, _ := abi.Encode([]string{"uint256", "bytes[]"}, big.NewInt(10), []any{okData})
+	backend, calls := multicall3OnlyBackend(t, aggregateOutput)
+
+	m, err := NewMultiCall(backend, nil, WithDetector(NewDetector()))
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+	if capabilities, ok := m.Capabilities(); !ok || !capabilities.Multicall3 {
+		t.Fatalf("Capabilities = %+v, %v", capabilities, ok)
+	}
+
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	result := m.AggregateStatic(NewCalls([]common.Address{target}, []string{"a()"}, nil, nil, [][]string{{"uint256"}}, nil), backend, nil, nil, nil)
+	if !result.Success {
+		t.Fatalf("AggregateStatic: %v", result.Error)
+	}
+	last := (*calls)[len(*calls)-1]
+	if *last.To != MULTICALL3_ADDRESS || !bytes.HasPrefix(last.Data, abi.EncodeSignature("aggregate((address,bytes)[])")) {
+		t.Fatalf("AggregateStatic was not routed to Multicall3: to %s, data %x", last.To, last.Data)
+	}
+	if value, err := DecodeCall[uint64](result, 0); err != nil || value != 7 {
+		t.Fatalf("DecodeCall = %d, %v", value, err)
+	}
+
+	if result := m.SimulateCall(nil, backend, nil, nil, nil); !errors.Is(result.Error, ErrNoMulticallContract) {
+		t.Fatalf("SimulateCall error = %v, want ErrNoMulticallContract", result.Error)
+	}
+}
+
+func TestNewMultiCall_DetectedPayloadLimit(t *testing.T) {
+	backend, _ := multicall3OnlyBackend(t, nil)
+	m, err := NewMultiCall(backend, nil, WithDetector(NewDetector()))
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := make(Calls, 5)
+	for i := range calls {
+		calls[i] = NewCall(target, "", nil, make([]byte, 40*1024), nil, nil)
+	}
+
+	bounds, err := m.chunkBounds(context.Background(), calls, backend, nil)
+	if err != nil {
+		t.Fatalf("chunkBounds: %v", err)
+	}
+	if want := [][2]int{{0, 2}, {2, 4}, {4, 5}}; len(bounds) != len(want) || bounds[0] != want[0] || bounds[1] != want[1] || bounds[2] != want[2] {
+		t.Fatalf("bounds = %v, want %v under the 128KiB payload limit", bounds, want)
+	}
+}
@@ -0,0 +1,176 @@
+package multicall
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/omnes-tech/abi"
+)
+
+var (
+	ErrNoSigner            = errors.New("no signer configured")
+	ErrNoMulticallContract = errors.New("no multicall contract on this chain")
+	ErrNoSimulationResult  = errors.New("call did not return simulation result")
+	// ErrCallFailed matches every *CallFailedError.
+	ErrCallFailed = errors.New("multicall call failed")
+	// ErrSendingValueNotAllowed is MultiCall__SendingValueNotAllowed(): a call carried
+	// value through a method that cannot forward it.
+	ErrSendingValueNotAllowed = errors.New("sending value not allowed")
+	// ErrInvalidStaticCallType matches every *InvalidStaticCallTypeError.
+	ErrInvalidStaticCallType = errors.New("invalid static call type")
+)
+
+var (
+	errorSelector                 = abi.EncodeSignature("Error(string)")
+	panicSelector                 = abi.EncodeSignature("Panic(uint256)")
+	callFailedSelector            = abi.EncodeSignature("MultiCall__CallFailed(uint256)")
+	staticCallFailedSelector      = abi.EncodeSignature("MultiCall__StaticCallFailed(uint256)")
+	sendingValueSelector          = abi.EncodeSignature("MultiCall__SendingValueNotAllowed()")
+	invalidStaticCallTypeSelector = abi.EncodeSignature("MultiCallCodec__InvalidStaticCallType(uint8)")
+)
+
+// CallFailedError reports the call that made a whole aggregated call revert,
+// decoded from MultiCall__CallFailed(uint256) or MultiCall__StaticCallFailed(uint256).
+type CallFailedError struct {
+	Index  int
+	Target common.Address
+	// Static is set when the failing call was a static call.
+	Static bool
+	// Revert is the revert of the failing call itself, when it is known.
+	Revert *RevertError
+}
+
+func (e *CallFailedError) Error() string {
+	kind := "call"
+	if e.Static {
+		kind = "static call"
+	}
+
+	msg := fmt.Sprintf("multicall: %s %d to %s failed", kind, e.Index, e.Target.Hex())
+	if e.Revert != nil {
+		msg += ": " + e.Revert.Error()
+	}
+	return msg
+}
+
+func (e *CallFailedError) Is(target error) bool {
+	return target == ErrCallFailed
+}
+
+func (e *CallFailedError) Unwrap() error {
+	if e.Revert == nil {
+		return nil
+	}
+	return e.Revert
+}
+
+// InvalidStaticCallTypeError is MultiCallCodec__InvalidStaticCallType(uint8): the
+// deployless contract was asked for a CallType it does not implement.
+type InvalidStaticCallTypeError struct {
+	Type uint8
+}
+
+func (e *InvalidStaticCallTypeError) Error() string {
+	return fmt.Sprintf("multicall: invalid static call type %d", e.Type)
+}
+
+func (e *InvalidStaticCallTypeError) Is(target error) bool {
+	return target == ErrInvalidStaticCallType
+}
+
+// decodeMultiCallError turns the revert data carried by err, as returned by the
+// deployed or deployless contract, into the matching typed error. calls, which may
+// be nil, is used to name the target of a failed call. The original error stays
+// in the chain.
+func decodeMultiCallError(err error, calls CallsInterface) error {
+	revertData, ok := parseRevertData(err)
+	if !ok || len(revertData) < 4 {
+		return err
+	}
+
+	var typed error
+	switch selector := revertData[:4]; {
+	case bytes.Equal(selector, callFailedSelector), bytes.Equal(selector, staticCallFailedSelector):
+		decoded, decodeErr := safeDecode([]string{"uint256"}, revertData[4:])
+		if decodeErr != nil {
+			return err
+		}
+
+		callFailed := &CallFailedError{
+			Index:  int(decoded[0].(*big.Int).Int64()),
+			Static: bytes.Equal(selector, staticCallFailedSelector),
+		}
+		if calls != nil && callFailed.Index >= 0 && callFailed.Index < calls.Len() {
+			callFailed.Target = *calls.GetTarget(callFailed.Index)
+		}
+		typed = callFailed
+	case bytes.Equal(selector, sendingValueSelector):
+		typed = ErrSendingValueNotAllowed
+	case bytes.Equal(selector, invalidStaticCallTypeSelector):
+		decoded, decodeErr := safeDecode([]string{"uint8"}, revertData[4:])
+		if decodeErr != nil {
+			return err
+		}
+		typed = &InvalidStaticCallTypeError{Type: uint8(decoded[0].(*big.Int).Uint64())}
+	default:
+		typed = newRevertError(revertData)
+	}
+
+	return fmt.Errorf("%w: %w", typed, err)
+}
+
+// RevertError is the decoded revert data of a failed call.
+type RevertError struct {
+	Data []byte
+	// Reason is the message of a require/revert with a string.
+	Reason string
+	// PanicCode is set when the call failed with Panic(uint256), e.g. 0x11 on overflow.
+	PanicCode *big.Int
+}
+
+func newRevertError(data []byte) *RevertError {
+	revert := &RevertError{Data: data}
+	if len(data) < 4 {
+		return revert
+	}
+
+	switch {
+	case bytes.Equal(data[:4], errorSelector):
+		decoded, err := safeDecode([]string{"string"}, data[4:])
+		if err == nil {
+			revert.Reason, _ = decoded[0].(string)
+		}
+	case bytes.Equal(data[:4], panicSelector):
+		decoded, err := safeDecode([]string{"uint256"}, data[4:])
+		if err == nil {
+			revert.PanicCode, _ = decoded[0].(*big.Int)
+		}
+	}
+
+	return revert
+}
+
+// Selector returns the 4-byte selector of a custom error, or nil.
+func (e *RevertError) Selector() []byte {
+	if len(e.Data) < 4 {
+		return nil
+	}
+	return e.Data[:4]
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Reason != "":
+		return fmt.Sprintf("execution reverted: %s", e.Reason)
+	case e.PanicCode != nil:
+		return fmt.Sprintf("execution reverted: panic 0x%x", e.PanicCode)
+	case len(e.Data) > 0:
+		return fmt.Sprintf("execution reverted: %s", hexutil.Encode(e.Data))
+	default:
+		return "execution reverted"
+	}
+}
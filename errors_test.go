@@ -0,0 +1,118 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/omnes-tech/abi"
+)
+
+// revertErr mimics the error a node returns for a reverted eth_call.
+type revertErr struct {
+	data []byte
+}
+
+func (e *revertErr) Error() string          { return "execution reverted" }
+func (e *revertErr) ErrorCode() int         { return 3 }
+func (e *revertErr) ErrorData() interface{} { return hexutil.Encode(e.data) }
+
+func encodeRevert(t *testing.T, signature string, args ...any) []byte {
+	t.Helper()
+
+	data, err := abi.EncodeWithSignature(signature, args...)
+	if err != nil {
+		t.Fatalf("encode %s: %v", signature, err)
+	}
+	return data
+}
+
+func TestDecodeMultiCallError(t *testing.T) {
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCalls([]common.Address{ZERO_ADDRESS, target}, []string{"a()", "b()"}, nil, nil, nil, nil)
+
+	t.Run("static call failed", func(t *testing.T) {
+		err := decodeMultiCallError(&revertErr{encodeRevert(t, "MultiCall__StaticCallFailed(uint256)", big.NewInt(1))}, calls)
+
+		var callFailed *CallFailedError
+		if !errors.As(err, &callFailed) {
+			t.Fatalf("err = %v, want *CallFailedError", err)
+		}
+		if callFailed.Index != 1 || callFailed.Target != target || !callFailed.Static {
+			t.Fatalf("CallFailedError = %+v", callFailed)
+		}
+		if !errors.Is(err, ErrCallFailed) {
+			t.Fatalf("errors.Is(err, ErrCallFailed) = false")
+		}
+	})
+
+	t.Run("sending value not allowed", func(t *testing.T) {
+		err := decodeMultiCallError(&revertErr{encodeRevert(t, "MultiCall__SendingValueNotAllowed()")}, calls)
+		if !errors.Is(err, ErrSendingValueNotAllowed) {
+			t.Fatalf("err = %v, want ErrSendingValueNotAllowed", err)
+		}
+	})
+
+	t.Run("invalid static call type", func(t *testing.T) {
+		err := decodeMultiCallError(
+			&revertErr{encodeRevert(t, "MultiCallCodec__InvalidStaticCallType(uint8)", big.NewInt(9))}, nil,
+		)
+
+		var invalid *InvalidStaticCallTypeError
+		if !errors.As(err, &invalid) || invalid.Type != 9 || !errors.Is(err, ErrInvalidStaticCallType) {
+			t.Fatalf("err = %v, want InvalidStaticCallTypeError{9}", err)
+		}
+	})
+
+	t.Run("revert reason", func(t *testing.T) {
+		err := decodeMultiCallError(&revertErr{encodeRevert(t, "Error(string)", "boom")}, calls)
+
+		var revert *RevertError
+		if !errors.As(err, &revert) || revert.Reason != "boom" {
+			t.Fatalf("err = %v, want RevertError(boom)", err)
+		}
+	})
+
+	t.Run("other errors are kept", func(t *testing.T) {
+		plain := errors.New("connection refused")
+		if err := decodeMultiCallError(plain, calls); err != plain {
+			t.Fatalf("err = %v, want %v", err, plain)
+		}
+	})
+}
+
+func TestMultiCall_TypedErrors(t *testing.T) {
+	revert := encodeRevert(t, "MultiCall__StaticCallFailed(uint256)", big.NewInt(0))
+	backend := &fakeBackend{
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			return &revertErr{revert}
+		},
+	}
+	calls := NewCalls([]common.Address{ZERO_ADDRESS}, []string{"a()"}, nil, nil, nil, nil)
+
+	for name, opt := range map[string]Option{"deployed": WithDeployedMode(), "deployless": WithDeploylessMode()} {
+		t.Run(name, func(t *testing.T) {
+			m, err := NewMultiCall(backend, nil, opt)
+			if err != nil {
+				t.Fatalf("NewMultiCall: %v", err)
+			}
+
+			result := m.AggregateStatic(calls, backend, nil, nil, nil)
+			var callFailed *CallFailedError
+			if !errors.As(result.Error, &callFailed) || callFailed.Index != 0 || callFailed.Target != ZERO_ADDRESS {
+				t.Fatalf("Error = %v, want *CallFailedError for call 0", result.Error)
+			}
+		})
+	}
+
+	m, err := NewMultiCall(backend, nil, WithDeployedMode())
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+	if result := m.AggregateCalls(calls, backend, nil, nil, false, nil); !errors.Is(result.Error, ErrNoSigner) {
+		t.Fatalf("Error = %v, want ErrNoSigner", result.Error)
+	}
+}
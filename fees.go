@@ -0,0 +1,88 @@
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// FeeStrategy derives EIP-1559 fees from eth_feeHistory: the tip is the median,
+// over Blocks recent blocks, of the Percentile-th priority fee paid in each
+// block, and the fee cap is the next base fee times BaseFeeMultiplier plus the
+// tip. Zero values take the FeeStrategyStandard ones. Chains without EIP-1559
+// fall back to legacy transactions priced with eth_gasPrice.
+type FeeStrategy struct {
+	Blocks     uint64
+	Percentile float64
+	// BaseFeeMultiplier leaves room for the base fee to rise before inclusion.
+	BaseFeeMultiplier float64
+}
+
+var (
+	FeeStrategySlow     = FeeStrategy{Blocks: 10, Percentile: 10, BaseFeeMultiplier: 1.25}
+	FeeStrategyStandard = FeeStrategy{Blocks: 10, Percentile: 50, BaseFeeMultiplier: 2}
+	FeeStrategyFast     = FeeStrategy{Blocks: 10, Percentile: 90, BaseFeeMultiplier: 2}
+)
+
+func (s FeeStrategy) withDefaults() FeeStrategy {
+	if s.Blocks == 0 {
+		s.Blocks = FeeStrategyStandard.Blocks
+	}
+	if s.Percentile == 0 {
+		s.Percentile = FeeStrategyStandard.Percentile
+	}
+	if s.BaseFeeMultiplier == 0 {
+		s.BaseFeeMultiplier = FeeStrategyStandard.BaseFeeMultiplier
+	}
+	return s
+}
+
+type feeHistory struct {
+	BaseFee []*hexutil.Big   `json:"baseFeePerGas"`
+	Reward  [][]*hexutil.Big `json:"reward"`
+}
+
+// suggestFees returns the fee cap and tip of a dynamic fee transaction, or the
+// gas price and a nil tip when the chain has no base fee.
+func suggestFees(ctx context.Context, client Backend, strategy FeeStrategy) (feeCap *big.Int, tipCap *big.Int, err error) {
+	strategy = strategy.withDefaults()
+
+	var history feeHistory
+	err = client.CallContext(
+		ctx, &history, "eth_feeHistory", hexutil.Uint64(strategy.Blocks), "latest", []float64{strategy.Percentile},
+	)
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+
+	// the last base fee is the one of the next block
+	if err != nil || len(history.BaseFee) == 0 || history.BaseFee[len(history.BaseFee)-1].ToInt().Sign() == 0 {
+		gasPrice, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting gas price: %w", err)
+		}
+		return gasPrice, nil, nil
+	}
+	baseFee := history.BaseFee[len(history.BaseFee)-1].ToInt()
+
+	var rewards []*big.Int
+	for _, reward := range history.Reward {
+		if len(reward) > 0 && reward[0] != nil {
+			rewards = append(rewards, reward[0].ToInt())
+		}
+	}
+	tipCap = new(big.Int)
+	if len(rewards) > 0 {
+		sort.Slice(rewards, func(i, j int) bool { return rewards[i].Cmp(rewards[j]) < 0 })
+		tipCap.Set(rewards[len(rewards)/2])
+	}
+
+	feeCap = new(big.Int).Mul(baseFee, big.NewInt(int64(strategy.BaseFeeMultiplier*1000)))
+	feeCap.Div(feeCap, big.NewInt(1000))
+	feeCap.Add(feeCap, tipCap)
+
+	return feeCap, tipCap, nil
+}
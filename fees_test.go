@@ -0,0 +1,90 @@
+package multicall
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func hexBigs(values ...int64) []*hexutil.Big {
+	bigs := make([]*hexutil.Big, len(values))
+	for i, value := range values {
+		bigs[i] = (*hexutil.Big)(big.NewInt(value))
+	}
+	return bigs
+}
+
+func TestSuggestFees(t *testing.T) {
+	history := &feeHistory{
+		BaseFee: hexBigs(90, 95, 100),
+		Reward:  [][]*hexutil.Big{hexBigs(5), hexBigs(1), hexBigs(3)},
+	}
+
+	tests := []struct {
+		name       string
+		backend    *fakeBackend
+		strategy   FeeStrategy
+		wantFeeCap int64
+		wantTipCap *big.Int
+	}{
+		{"median tip over twice the next base fee", &fakeBackend{feeHistory: history}, FeeStrategy{}, 203, big.NewInt(3)},
+		{"base fee multiplier", &fakeBackend{feeHistory: history}, FeeStrategySlow, 128, big.NewInt(3)},
+		{"legacy without fee history", &fakeBackend{gasPrice: big.NewInt(7)}, FeeStrategy{}, 7, nil},
+		{
+			"legacy without base fee",
+			&fakeBackend{gasPrice: big.NewInt(7), feeHistory: &feeHistory{BaseFee: hexBigs(0, 0)}},
+			FeeStrategy{}, 7, nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feeCap, tipCap, err := suggestFees(context.Background(), tt.backend, tt.strategy)
+			if err != nil {
+				t.Fatalf("suggestFees: %v", err)
+			}
+			if feeCap.Int64() != tt.wantFeeCap {
+				t.Fatalf("feeCap = %s, want %d", feeCap, tt.wantFeeCap)
+			}
+			if (tipCap == nil) != (tt.wantTipCap == nil) || (tipCap != nil && tipCap.Cmp(tt.wantTipCap) != 0) {
+				t.Fatalf("tipCap = %v, want %v", tipCap, tt.wantTipCap)
+			}
+		})
+	}
+}
+
+func TestCreateTransaction_DynamicFee(t *testing.T) {
+	backend := &fakeBackend{
+		gasPrice:   big.NewInt(7),
+		gas:        50000,
+		nonce:      2,
+		feeHistory: &feeHistory{BaseFee: hexBigs(100), Reward: [][]*hexutil.Big{hexBigs(4)}},
+		// the chain ID is passed in, never read from the backend
+		chainID: big.NewInt(5),
+	}
+	from := common.HexToAddress("0x000000000000000000000000000000000000a11c")
+	to := common.HexToAddress("0x000000000000000000000000000000000000beef")
+
+	tx, err := createTransaction(context.Background(), backend, big.NewInt(1), &from, &to, nil, []byte{0x01}, FeeStrategyStandard, nil)
+	if err != nil {
+		t.Fatalf("createTransaction: %v", err)
+	}
+	if tx.Type() != types.DynamicFeeTxType || tx.GasFeeCap().Int64() != 204 || tx.GasTipCap().Int64() != 4 {
+		t.Fatalf("tx = type %d, fee cap %s, tip cap %s", tx.Type(), tx.GasFeeCap(), tx.GasTipCap())
+	}
+	if tx.ChainId().Int64() != 1 {
+		t.Fatalf("ChainId = %s, want the given chain ID", tx.ChainId())
+	}
+	if estimated := backend.estimated[0]; estimated.GasFeeCap.Int64() != 204 || estimated.GasPrice != nil {
+		t.Fatalf("estimated with %+v, want the dynamic fees", estimated)
+	}
+
+	txOrCall := FromTxToTxOrCall(tx, from, nil, nil)
+	if txOrCall.GasFeeCap.Int64() != 204 || txOrCall.GasTipCap.Int64() != 4 {
+		t.Fatalf("TxOrCall fees = %s, %s", txOrCall.GasFeeCap, txOrCall.GasTipCap)
+	}
+}
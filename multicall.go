@@ -7,159 +7,313 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 type MultiCall struct {
 	ContractAddress *common.Address
-	Signer          *SignerInterface
+	// Version is the version of the contract at ContractAddress, when its code hash is registered.
+	Version string
+	Signer  *SignerInterface
+	// Defaults holds the sender, block and state overrides used when a call leaves them unset.
+	Defaults Overrides
+	// Chunking bounds the batches sent by SimulateCall and the static aggregate methods.
+	Chunking ChunkOptions
+	// Fees prices the transactions sent by the write methods.
+	Fees FeeStrategy
+	// Replacement bumps the fees of sent transactions that are not mined in time.
+	Replacement ReplacementPolicy
+	// Nonces allocates the nonces of the sent transactions, shared by all write methods.
+	Nonces *NonceManager
+	// Delegation, when set, makes the aggregate write methods execute from the signer's EOA through EIP-7702.
+	Delegation *Delegation
+
+	logger Logger
+	// deployment is the block ContractAddress was deployed at, when registered.
+	deployment *big.Int
+	// capabilities is set when the chain was probed with a Detector.
+	capabilities *Capabilities
+	// multicall3 serves the operations routed to Multicall3 or Multicall2.
+	multicall3 *MultiCall3
 }
 
-func NewMultiCall(client *ethclient.Client, signer *SignerInterface) (*MultiCall, error) {
-	var multicallAddress *common.Address
+func NewMultiCall(client Backend, signer *SignerInterface, opts ...Option) (*MultiCall, error) {
+	return NewMultiCallContext(context.Background(), client, signer, opts...)
+}
 
-	bytecode, err := client.CodeAt(context.Background(), OMNES_MULTICALL_ADDRESS, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error getting bytecode: %v", err)
+// NewMultiCallContext is like NewMultiCall but probes the chain for the multicall
+// contract using ctx.
+func NewMultiCallContext(ctx context.Context, client Backend, signer *SignerInterface, opts ...Option) (*MultiCall, error) {
+	o := options{logger: log.Default(), nonces: NewNonceManager()}
+	for _, opt := range opts {
+		opt(&o)
 	}
 
-	if len(bytecode) == 0 {
-		log.Printf("no deployed contract found. Using deployless method\n\n")
+	address := OMNES_MULTICALL_ADDRESS
+	if o.contractAddress != nil {
+		address = *o.contractAddress
+	}
 
+	var multicallAddress *common.Address
+	var version string
+	var ok bool
+	switch {
+	case o.mode == deployedMode:
+		multicallAddress = &address
+	case o.mode == deploylessMode:
 		multicallAddress = nil
-	} else {
-		multicallAddress = &OMNES_MULTICALL_ADDRESS
+	case o.detector != nil:
+		return newDetectedMultiCall(ctx, client, signer, o, address)
+	default:
+		bytecode, err := client.CodeAt(ctx, address, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting bytecode: %w", err)
+		}
+
+		if len(bytecode) == 0 {
+			o.logger.Printf("no deployed contract found. Using deployless method\n\n")
+
+			multicallAddress = nil
+		} else if version, ok = trustedCode(bytecode); !ok {
+			o.logger.Printf("code at %s does not match a known multicall version. Using deployless method\n\n", address.Hex())
+
+			multicallAddress = nil
+		} else {
+			multicallAddress = &address
+		}
+	}
+
+	var deployment *big.Int
+	if multicallAddress != nil {
+		var err error
+		if deployment, err = deploymentBlock(ctx, client, *multicallAddress); err != nil {
+			return nil, err
+		}
 	}
 
 	return &MultiCall{
 		ContractAddress: multicallAddress,
+		Version:         version,
+		deployment:      deployment,
 		Signer:          signer,
+		Defaults:        o.defaults,
+		Chunking:        o.chunking,
+		Fees:            o.fees,
+		Replacement:     o.replacement,
+		Nonces:          o.nonces,
+		Delegation:      o.delegation,
+		logger:          o.logger,
 	}, nil
 
 }
 
 func (m *MultiCall) AggregateCalls(
-	calls []Call, client *ethclient.Client, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+	calls []Call, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
 ) Result {
-	if m.Signer == nil && !isCall {
-		return Result{Success: false, Error: fmt.Errorf("no signer configured")}
-	}
-	if m.ContractAddress == nil {
-		return Result{Success: false, Error: fmt.Errorf("no multicall contract on this chain")}
-	}
-
-	if isCall {
-		return txAsRead(
-			calls,
-			false,
-			client,
-			from,
-			m.ContractAddress,
-			"aggregateCalls((address,bytes,uint256)[])",
-			[]string{"bytes[]"},
-			blockNumber,
-			overrides,
-		)
-	} else {
-		return transact(
-			calls,
-			false,
-			client,
-			*m.Signer,
-			m.ContractAddress,
-			"aggregateCalls((address,bytes,uint256)[])",
-			[]string{"bytes[]"},
-			true,
-			false,
+	return m.AggregateCallsContext(context.Background(), calls, client, from, blockNumber, isCall, overrides)
+}
+
+// AggregateCallsContext is like AggregateCalls but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) AggregateCallsContext(
+	ctx context.Context, calls []Call, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.resolve(from, blockNumber, overrides)
+
+	if !isCall {
+		return m.aggregateWrite(
+			ctx, Calls(calls), true, client, blockNumber, overrides, "aggregateCalls((address,bytes,uint256)[])", []string{"bytes[]"},
 		)
 	}
+	if m.Delegation != nil {
+		return m.delegatedAggregateCalls(ctx, withRequireSuccess(calls, true), client, from, blockNumber, overrides)
+	}
+	if via, err := m.viaMultiCall3(OperationPreview, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	} else if via {
+		return m.multicall3.Aggregate3ValueContext(ctx, withRequireSuccess(calls, true), client, from, blockNumber, true, overrides)
+	}
+	if m.ContractAddress == nil || !m.deployedAt(blockNumber) {
+		return deploylessAggregateCalls(ctx, calls, func(int) bool { return true }, client, from, blockNumber, overrides)
+	}
 
+	return txAsRead(
+		ctx,
+		calls,
+		false,
+		client,
+		from,
+		m.ContractAddress,
+		"aggregateCalls((address,bytes,uint256)[])",
+		[]string{"bytes[]"},
+		blockNumber,
+		overrides,
+	)
 }
 
 func (m *MultiCall) TryAggregateCalls(
-	calls []Call, requireSuccess bool, client *ethclient.Client, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+	calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
 ) Result {
-	if m.Signer == nil && !isCall {
-		return Result{Success: false, Error: fmt.Errorf("no signer configured")}
-	}
-	if m.ContractAddress == nil {
-		return Result{Success: false, Error: fmt.Errorf("no multicall contract on this chain")}
-	}
-
-	if isCall {
-		return txAsRead(
-			calls,
-			requireSuccess,
-			client,
-			from,
-			m.ContractAddress,
-			"tryAggregateCalls((address,bytes,uint256)[],bool)",
-			[]string{"(bool,bytes)[]"},
-			blockNumber,
-			overrides,
-		)
-	} else {
-		return transact(
-			calls,
-			requireSuccess,
-			client,
-			*m.Signer,
-			m.ContractAddress,
-			"tryAggregateCalls((address,bytes,uint256)[],bool)",
-			[]string{"(bool,bytes)[]"},
-			true,
-			false,
+	return m.TryAggregateCallsContext(context.Background(), calls, requireSuccess, client, from, blockNumber, isCall, overrides)
+}
+
+// TryAggregateCallsContext is like TryAggregateCalls but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) TryAggregateCallsContext(
+	ctx context.Context, calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.resolve(from, blockNumber, overrides)
+
+	if !isCall {
+		return m.aggregateWrite(
+			ctx, Calls(calls), requireSuccess, client, blockNumber, overrides,
+			"tryAggregateCalls((address,bytes,uint256)[],bool)", []string{"(bool,bytes)[]"},
 		)
 	}
+	if m.Delegation != nil {
+		return m.delegatedAggregateCalls(ctx, withRequireSuccess(calls, requireSuccess), client, from, blockNumber, overrides)
+	}
+	if via, err := m.viaMultiCall3(OperationPreview, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	} else if via {
+		return m.multicall3.Aggregate3ValueContext(ctx, withRequireSuccess(calls, requireSuccess), client, from, blockNumber, true, overrides)
+	}
+	if m.ContractAddress == nil || !m.deployedAt(blockNumber) {
+		return deploylessAggregateCalls(ctx, calls, func(int) bool { return requireSuccess }, client, from, blockNumber, overrides)
+	}
 
+	return txAsRead(
+		ctx,
+		calls,
+		requireSuccess,
+		client,
+		from,
+		m.ContractAddress,
+		"tryAggregateCalls((address,bytes,uint256)[],bool)",
+		[]string{"(bool,bytes)[]"},
+		blockNumber,
+		overrides,
+	)
 }
 
 func (m *MultiCall) TryAggregateCalls3(
-	calls []CallWithFailure, client *ethclient.Client, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+	calls []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	return m.TryAggregateCalls3Context(context.Background(), calls, client, from, blockNumber, isCall, overrides)
+}
+
+// TryAggregateCalls3Context is like TryAggregateCalls3 but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) TryAggregateCalls3Context(
+	ctx context.Context, calls []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
 ) Result {
-	if m.Signer == nil && !isCall {
-		return Result{Success: false, Error: fmt.Errorf("no signer configured")}
-	}
-	if m.ContractAddress == nil {
-		return Result{Success: false, Error: fmt.Errorf("no multicall contract on this chain")}
-	}
-
-	if isCall {
-		return txAsReadWithFailure(
-			calls,
-			false,
-			client,
-			from,
-			m.ContractAddress,
-			"tryAggregateCalls((address,bytes,uint256,bool)[])",
-			[]string{"(bool,bytes)[]"},
-			blockNumber,
-			overrides,
+	from, blockNumber, overrides = m.resolve(from, blockNumber, overrides)
+
+	if !isCall {
+		return m.aggregateWrite(
+			ctx, CallsWithFailure(calls), false, client, blockNumber, overrides,
+			"tryAggregateCalls((address,bytes,uint256,bool)[])", []string{"(bool,bytes)[]"},
 		)
-	} else {
-		return transactWithFailure(
-			calls,
-			false,
-			client,
-			*m.Signer,
-			m.ContractAddress,
-			"tryAggregateCalls((address,bytes,uint256,bool)[])",
-			[]string{"(bool,bytes)[]"},
-			true,
-			false,
+	}
+	if m.Delegation != nil {
+		return m.delegatedAggregateCalls(ctx, calls, client, from, blockNumber, overrides)
+	}
+	if via, err := m.viaMultiCall3(OperationPreview, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	} else if via {
+		return m.multicall3.Aggregate3ValueContext(ctx, calls, client, from, blockNumber, true, overrides)
+	}
+	if m.ContractAddress == nil || !m.deployedAt(blockNumber) {
+		return deploylessAggregateCalls(
+			ctx, CallsWithFailure(calls).toCalls(), CallsWithFailure(calls).GetRequireSuccess, client, from, blockNumber, overrides,
 		)
 	}
 
+	return txAsReadWithFailure(
+		ctx,
+		calls,
+		false,
+		client,
+		from,
+		m.ContractAddress,
+		"tryAggregateCalls((address,bytes,uint256,bool)[])",
+		[]string{"(bool,bytes)[]"},
+		blockNumber,
+		overrides,
+	)
 }
 
 func (m *MultiCall) SimulateCall(
-	calls []Call, client *ethclient.Client, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+	calls []Call, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	return m.SimulateCallContext(context.Background(), calls, client, from, blockNumber, overrides)
+}
+
+// SimulateCallContext is like SimulateCall but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) SimulateCallContext(
+	ctx context.Context, calls []Call, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.resolve(from, blockNumber, overrides)
+
+	bounds, err := m.chunkBounds(ctx, Calls(calls), client, from)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+	if len(bounds) > 1 {
+		return m.runChunks(ctx, client, blockNumber, bounds, func(ctx context.Context, lo, hi int, blockNumber *big.Int) Result {
+			return m.simulateCall(ctx, calls[lo:hi], client, from, blockNumber, overrides)
+		})
+	}
+
+	return m.simulateCall(ctx, calls, client, from, blockNumber, overrides)
+}
+
+func (m *MultiCall) SimulateDelegateCall(
+	calls []Call, account common.Address, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	return m.SimulateDelegateCallContext(context.Background(), calls, account, client, from, blockNumber, overrides)
+}
+
+// SimulateDelegateCallContext is like SimulateCall but delegate calls each target
+// in the context of account, e.g. a smart wallet running a module or library,
+// so the calls read and write account's storage and balance. Later calls see
+// the state changes of earlier ones. account's code is replaced by the
+// simulator through a state override, so calls back into account's own
+// functions are not supported. Calls cannot carry value.
+func (m *MultiCall) SimulateDelegateCallContext(
+	ctx context.Context, calls []Call, account common.Address, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.resolve(from, blockNumber, overrides)
+
+	if m.capabilities != nil && !m.capabilities.StateOverrides {
+		return Result{Success: false, Error: fmt.Errorf("delegate call simulation needs state overrides, unsupported on chain %v", m.capabilities.ChainID)}
+	}
+
+	bounds, err := m.chunkBounds(ctx, Calls(calls), client, from)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+	if len(bounds) > 1 {
+		return m.runChunks(ctx, client, blockNumber, bounds, func(ctx context.Context, lo, hi int, blockNumber *big.Int) Result {
+			return deploylessDelegateSimulation(ctx, calls[lo:hi], account, client, from, blockNumber, overrides)
+		})
+	}
+
+	return deploylessDelegateSimulation(ctx, calls, account, client, from, blockNumber, overrides)
+}
+
+func (m *MultiCall) simulateCall(
+	ctx context.Context, calls []Call, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
 ) Result {
-	if m.ContractAddress == nil {
-		return deploylessSimulation(calls, client, from, blockNumber, overrides)
+	if _, err := m.strategy(OperationSimulate, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	}
+	if !m.deployedAt(blockNumber) {
+		return deploylessSimulation(ctx, calls, client, from, blockNumber, overrides)
 	}
 
 	return call(
+		ctx,
 		calls,
 		false,
 		client,
@@ -177,13 +331,45 @@ func (m *MultiCall) SimulateCall(
 }
 
 func (m *MultiCall) AggregateStatic(
-	calls []Call, client *ethclient.Client, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+	calls []Call, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
 ) Result {
-	if m.ContractAddress == nil {
-		return deploylessAggregateStatic(calls, client, from, blockNumber, overrides)
+	return m.AggregateStaticContext(context.Background(), calls, client, from, blockNumber, overrides)
+}
+
+// AggregateStaticContext is like AggregateStatic but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) AggregateStaticContext(
+	ctx context.Context, calls []Call, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.resolve(from, blockNumber, overrides)
+
+	bounds, err := m.chunkBounds(ctx, Calls(calls), client, from)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+	if len(bounds) > 1 {
+		return m.runChunks(ctx, client, blockNumber, bounds, func(ctx context.Context, lo, hi int, blockNumber *big.Int) Result {
+			return m.aggregateStatic(ctx, calls[lo:hi], client, from, blockNumber, overrides)
+		})
+	}
+
+	return m.aggregateStatic(ctx, calls, client, from, blockNumber, overrides)
+}
+
+func (m *MultiCall) aggregateStatic(
+	ctx context.Context, calls []Call, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	if via, err := m.viaMultiCall3(OperationStatic, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	} else if via {
+		return m.multicall3.AggregateContext(ctx, calls, client, from, blockNumber, true, overrides)
+	}
+	if !m.deployedAt(blockNumber) {
+		return deploylessAggregateStatic(ctx, calls, client, from, blockNumber, overrides)
 	}
 
 	return call(
+		ctx,
 		calls,
 		false,
 		client,
@@ -201,13 +387,45 @@ func (m *MultiCall) AggregateStatic(
 }
 
 func (m *MultiCall) TryAggregateStatic(
-	calls []Call, requireSuccess bool, client *ethclient.Client, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+	calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	return m.TryAggregateStaticContext(context.Background(), calls, requireSuccess, client, from, blockNumber, overrides)
+}
+
+// TryAggregateStaticContext is like TryAggregateStatic but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) TryAggregateStaticContext(
+	ctx context.Context, calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.resolve(from, blockNumber, overrides)
+
+	bounds, err := m.chunkBounds(ctx, Calls(calls), client, from)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+	if len(bounds) > 1 {
+		return m.runChunks(ctx, client, blockNumber, bounds, func(ctx context.Context, lo, hi int, blockNumber *big.Int) Result {
+			return m.tryAggregateStatic(ctx, calls[lo:hi], requireSuccess, client, from, blockNumber, overrides)
+		})
+	}
+
+	return m.tryAggregateStatic(ctx, calls, requireSuccess, client, from, blockNumber, overrides)
+}
+
+func (m *MultiCall) tryAggregateStatic(
+	ctx context.Context, calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
 ) Result {
-	if m.ContractAddress == nil {
-		return deploylessTryAggregateStatic(calls, requireSuccess, client, from, blockNumber, overrides)
+	if via, err := m.viaMultiCall3(OperationStatic, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	} else if via {
+		return m.multicall3.TryAggregateContext(ctx, calls, requireSuccess, client, from, blockNumber, true, overrides)
+	}
+	if !m.deployedAt(blockNumber) {
+		return deploylessTryAggregateStatic(ctx, calls, requireSuccess, client, from, blockNumber, overrides)
 	}
 
 	return call(
+		ctx,
 		calls,
 		requireSuccess,
 		client,
@@ -225,13 +443,48 @@ func (m *MultiCall) TryAggregateStatic(
 }
 
 func (m *MultiCall) TryAggregateStatic3(
-	calls []CallWithFailure, client *ethclient.Client, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+	calls []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	return m.TryAggregateStatic3Context(context.Background(), calls, client, from, blockNumber, overrides)
+}
+
+// TryAggregateStatic3Context is like TryAggregateStatic3 but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) TryAggregateStatic3Context(
+	ctx context.Context, calls []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.resolve(from, blockNumber, overrides)
+
+	bounds, err := m.chunkBounds(ctx, CallsWithFailure(calls), client, from)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+	if len(bounds) > 1 {
+		return m.runChunks(ctx, client, blockNumber, bounds, func(ctx context.Context, lo, hi int, blockNumber *big.Int) Result {
+			return m.tryAggregateStatic3(ctx, calls[lo:hi], client, from, blockNumber, overrides)
+		})
+	}
+
+	return m.tryAggregateStatic3(ctx, calls, client, from, blockNumber, overrides)
+}
+
+func (m *MultiCall) tryAggregateStatic3(
+	ctx context.Context, calls []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
 ) Result {
-	if m.ContractAddress == nil {
-		return deploylessTryAggregateStatic3(calls, client, from, blockNumber, overrides)
+	if via, err := m.viaMultiCall3(OperationStatic, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	} else if via {
+		return requireCalls(
+			m.multicall3.TryAggregateContext(ctx, CallsWithFailure(calls).toCalls(), false, client, from, blockNumber, true, overrides),
+			calls, true,
+		)
+	}
+	if !m.deployedAt(blockNumber) {
+		return deploylessTryAggregateStatic3(ctx, calls, client, from, blockNumber, overrides)
 	}
 
 	return callWithFailure(
+		ctx,
 		calls,
 		client,
 		from,
@@ -246,13 +499,27 @@ func (m *MultiCall) TryAggregateStatic3(
 }
 
 func (m *MultiCall) CodeLengths(
-	addresses []*common.Address, client *ethclient.Client, blockNumber *big.Int,
+	addresses []*common.Address, client Backend, blockNumber *big.Int,
+) Result {
+	return m.CodeLengthsContext(context.Background(), addresses, client, blockNumber)
+}
+
+// CodeLengthsContext is like CodeLengths but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) CodeLengthsContext(
+	ctx context.Context, addresses []*common.Address, client Backend, blockNumber *big.Int,
 ) Result {
-	if m.ContractAddress == nil {
-		return deploylessGetCodeLengths(addresses, client, blockNumber)
+	blockNumber = m.resolveBlockNumber(blockNumber)
+
+	if _, err := m.strategy(OperationAccountData, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	}
+	if !m.deployedAt(blockNumber) {
+		return deploylessGetCodeLengths(ctx, addresses, client, blockNumber)
 	}
 
 	return getData(
+		ctx,
 		addresses,
 		client,
 		m.ContractAddress,
@@ -264,13 +531,29 @@ func (m *MultiCall) CodeLengths(
 }
 
 func (m *MultiCall) Balances(
-	addresses []*common.Address, client *ethclient.Client, blockNumber *big.Int,
+	addresses []*common.Address, client Backend, blockNumber *big.Int,
+) Result {
+	return m.BalancesContext(context.Background(), addresses, client, blockNumber)
+}
+
+// BalancesContext is like Balances but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) BalancesContext(
+	ctx context.Context, addresses []*common.Address, client Backend, blockNumber *big.Int,
 ) Result {
-	if m.ContractAddress == nil {
-		return deploylessGetBalances(addresses, client, blockNumber)
+	blockNumber = m.resolveBlockNumber(blockNumber)
+
+	if via, err := m.viaMultiCall3(OperationBalances, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	} else if via {
+		return m.multicall3.BalancesContext(ctx, addresses, client, blockNumber)
+	}
+	if !m.deployedAt(blockNumber) {
+		return deploylessGetBalances(ctx, addresses, client, blockNumber)
 	}
 
 	return getData(
+		ctx,
 		addresses,
 		client,
 		m.ContractAddress,
@@ -281,13 +564,27 @@ func (m *MultiCall) Balances(
 }
 
 func (m *MultiCall) AddressesData(
-	addresses []*common.Address, client *ethclient.Client, blockNumber *big.Int,
+	addresses []*common.Address, client Backend, blockNumber *big.Int,
+) Result {
+	return m.AddressesDataContext(context.Background(), addresses, client, blockNumber)
+}
+
+// AddressesDataContext is like AddressesData but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) AddressesDataContext(
+	ctx context.Context, addresses []*common.Address, client Backend, blockNumber *big.Int,
 ) Result {
-	if m.ContractAddress == nil {
-		return deploylessGetAddressesData(addresses, client, blockNumber)
+	blockNumber = m.resolveBlockNumber(blockNumber)
+
+	if _, err := m.strategy(OperationAccountData, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	}
+	if !m.deployedAt(blockNumber) {
+		return deploylessGetAddressesData(ctx, addresses, client, blockNumber)
 	}
 
 	return getData(
+		ctx,
 		addresses,
 		client,
 		m.ContractAddress,
@@ -297,31 +594,169 @@ func (m *MultiCall) AddressesData(
 	)
 }
 
-func (m *MultiCall) ChainData(client *ethclient.Client, blockNumber *big.Int) Result {
-	if m.ContractAddress == nil {
-		return deploylessGetChainData(client, blockNumber)
+func (m *MultiCall) ChainData(client Backend, blockNumber *big.Int) Result {
+	return m.ChainDataContext(context.Background(), client, blockNumber)
+}
+
+// ChainDataContext is like ChainData but issues every RPC with ctx, so cancellation and
+// deadlines propagate and surface in Result.Error.
+func (m *MultiCall) ChainDataContext(ctx context.Context, client Backend, blockNumber *big.Int) Result {
+	blockNumber = m.resolveBlockNumber(blockNumber)
+
+	if _, err := m.strategy(OperationChainData, blockNumber); err != nil {
+		return Result{Success: false, Error: err}
+	}
+	if !m.deployedAt(blockNumber) {
+		return deploylessGetChainData(ctx, client, blockNumber)
 	}
 
 	return getData(
+		ctx,
 		nil,
 		client,
 		m.ContractAddress,
 		"getChainData()",
-		[]string{
-			"uint256",
-			"uint256",
-			"bytes32",
-			"uint256",
-			"address",
-			"uint256",
-			"uint256",
-			"uint256",
-			"uint256",
-		},
+		chainDataTypes,
 		blockNumber,
 	)
 }
 
+// resolve falls back to m.Defaults for whatever the caller left unset.
+func (m *MultiCall) resolve(
+	from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) (*common.Address, *big.Int, StateOverride) {
+	return m.Defaults.resolve(from, blockNumber, overrides)
+}
+
+func (m *MultiCall) resolveBlockNumber(blockNumber *big.Int) *big.Int {
+	return m.Defaults.resolveBlockNumber(blockNumber)
+}
+
+func newDetectedMultiCall(
+	ctx context.Context, client Backend, signer *SignerInterface, o options, address common.Address,
+) (*MultiCall, error) {
+	capabilities, err := o.detector.Detect(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// the detector only knows about OMNES_MULTICALL_ADDRESS
+	bytecode, err := client.CodeAt(ctx, address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting bytecode: %w", err)
+	}
+	version, trusted := trustedCode(bytecode)
+	capabilities.Omnes = len(bytecode) > 0 && trusted
+
+	m := &MultiCall{
+		Signer:       signer,
+		Defaults:     o.defaults,
+		Chunking:     o.chunking,
+		Fees:         o.fees,
+		Replacement:  o.replacement,
+		Nonces:       o.nonces,
+		Delegation:   o.delegation,
+		logger:       o.logger,
+		capabilities: &capabilities,
+	}
+	if capabilities.Omnes {
+		m.ContractAddress = &address
+		m.Version = version
+		if m.deployment, err = deploymentBlock(ctx, client, address); err != nil {
+			return nil, err
+		}
+	}
+
+	var fallback common.Address
+	switch {
+	case capabilities.Multicall3:
+		fallback = MULTICALL3_ADDRESS
+	case capabilities.Multicall2:
+		fallback = MULTICALL2_ADDRESS
+	}
+	if fallback != (common.Address{}) {
+		fallbackDeployment, err := deploymentBlock(ctx, client, fallback)
+		if err != nil {
+			return nil, err
+		}
+		m.multicall3 = &MultiCall3{
+			ContractAddress: &fallback,
+			Signer:          signer,
+			Fees:            o.fees,
+			Replacement:     o.replacement,
+			Nonces:          o.nonces,
+			deployment:      fallbackDeployment,
+		}
+	}
+
+	return m, nil
+}
+
+// Capabilities returns what a Detector found on the chain, or false when
+// the MultiCall was not built with WithDetector.
+func (m *MultiCall) Capabilities() (Capabilities, bool) {
+	if m.capabilities == nil {
+		return Capabilities{}, false
+	}
+	return *m.capabilities, true
+}
+
+// strategy picks how op is served at blockNumber. Without detected capabilities
+// this is the Omnes contract when it exists at that block and deployless calls otherwise.
+func (m *MultiCall) strategy(op Operation, blockNumber *big.Int) (Strategy, error) {
+	if m.capabilities == nil {
+		if m.deployedAt(blockNumber) {
+			return StrategyOmnes, nil
+		}
+		return StrategyDeployless, nil
+	}
+
+	capabilities := *m.capabilities
+	capabilities.Omnes = capabilities.Omnes && m.deployedAt(blockNumber)
+	if m.multicall3 != nil && !deployedAt(m.multicall3.deployment, blockNumber) {
+		capabilities.Multicall3, capabilities.Multicall2 = false, false
+	}
+
+	return capabilities.Strategy(op)
+}
+
+// deployedAt reports whether the contract at ContractAddress exists at blockNumber.
+func (m *MultiCall) deployedAt(blockNumber *big.Int) bool {
+	return m.ContractAddress != nil && deployedAt(m.deployment, blockNumber)
+}
+
+// viaMultiCall3 reports whether op is routed to the Multicall3 or Multicall2 contract.
+func (m *MultiCall) viaMultiCall3(op Operation, blockNumber *big.Int) (bool, error) {
+	strategy, err := m.strategy(op, blockNumber)
+	if err != nil {
+		return false, err
+	}
+
+	return strategy == StrategyMultiCall3 || strategy == StrategyMultiCall2, nil
+}
+
+// requireCalls fails result with a *CallFailedError for the first failed call
+// of calls with RequireSuccess set, as tryAggregateStatic((address,bytes,bool)[]) would.
+// static tells whether the calls were static ones.
+func requireCalls(result Result, calls []CallWithFailure, static bool) Result {
+	for _, callResult := range result.CallResults() {
+		if !callResult.Success && calls[callResult.Index].RequireSuccess {
+			return Result{
+				Success: false,
+				Error: &CallFailedError{
+					Index:  callResult.Index,
+					Target: callResult.Target,
+					Static: static,
+					Revert: callResult.Revert,
+				},
+				TxOrCall: result.TxOrCall,
+			}
+		}
+	}
+
+	return result
+}
+
 // IsDeployed checks if the multicall contract is deployed on the chain.
 func (m *MultiCall) IsDeployed() bool {
 	return m.ContractAddress != nil
@@ -0,0 +1,524 @@
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/omnes-tech/abi"
+)
+
+// MultiCall3 runs batches through a Multicall3 contract, for chains where the
+// Omnes contract isn't deployed but Multicall3 is. Multicall3 has no deployless
+// mode and no simulation, so it only offers the operations of its own ABI.
+type MultiCall3 struct {
+	ContractAddress *common.Address
+	Signer          *SignerInterface
+	// Defaults holds the sender, block and state overrides used when a call leaves them unset.
+	Defaults Overrides
+	// Fees prices the transactions sent when isCall is false.
+	Fees FeeStrategy
+	// Replacement bumps the fees of sent transactions that are not mined in time.
+	Replacement ReplacementPolicy
+	// Nonces allocates the nonces of the sent transactions.
+	Nonces *NonceManager
+
+	// deployment is the block ContractAddress was deployed at, when registered.
+	deployment *big.Int
+}
+
+func NewMultiCall3(client Backend, signer *SignerInterface, opts ...Option) (*MultiCall3, error) {
+	return NewMultiCall3Context(context.Background(), client, signer, opts...)
+}
+
+// NewMultiCall3Context is like NewMultiCall3 but checks for the contract using ctx.
+// WithContractAddress replaces MULTICALL3_ADDRESS and WithDeployedMode skips the
+// check; WithDeploylessMode is not supported.
+func NewMultiCall3Context(ctx context.Context, client Backend, signer *SignerInterface, opts ...Option) (*MultiCall3, error) {
+	o := options{logger: nopLogger{}, nonces: NewNonceManager()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	address := MULTICALL3_ADDRESS
+	if o.contractAddress != nil {
+		address = *o.contractAddress
+	}
+
+	switch o.mode {
+	case deployedMode:
+	case deploylessMode:
+		return nil, fmt.Errorf("multicall3 has no deployless mode")
+	default:
+		bytecode, err := client.CodeAt(ctx, address, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting bytecode: %w", err)
+		}
+		if len(bytecode) == 0 {
+			return nil, fmt.Errorf("%w: no code at %s", ErrNoMulticallContract, address.Hex())
+		}
+	}
+
+	deployment, err := deploymentBlock(ctx, client, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MultiCall3{
+		ContractAddress: &address,
+		Signer:          signer,
+		Defaults:        o.defaults,
+		Fees:            o.fees,
+		Replacement:     o.replacement,
+		Nonces:          o.nonces,
+		deployment:      deployment,
+	}, nil
+}
+
+// Aggregate runs calls through aggregate, reverting if any of them fails. Values of calls are ignored.
+func (m *MultiCall3) Aggregate(
+	calls []Call, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	return m.AggregateContext(context.Background(), calls, client, from, blockNumber, isCall, overrides)
+}
+
+// AggregateContext is like Aggregate but issues every RPC with ctx.
+func (m *MultiCall3) AggregateContext(
+	ctx context.Context, calls []Call, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.Defaults.resolve(from, blockNumber, overrides)
+
+	arrayfiedCalls, _, err := Calls(calls).ToArray(false, true)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+
+	return m.aggregate(
+		ctx,
+		Calls(calls),
+		client,
+		from,
+		blockNumber,
+		isCall,
+		overrides,
+		nil,
+		"aggregate((address,bytes)[])",
+		[]string{"uint256", "bytes[]"},
+		arrayfiedCalls,
+	)
+}
+
+// TryAggregate runs calls through tryAggregate, reverting on a failed call only if requireSuccess is set.
+func (m *MultiCall3) TryAggregate(
+	calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	return m.TryAggregateContext(context.Background(), calls, requireSuccess, client, from, blockNumber, isCall, overrides)
+}
+
+// TryAggregateContext is like TryAggregate but issues every RPC with ctx.
+func (m *MultiCall3) TryAggregateContext(
+	ctx context.Context, calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.Defaults.resolve(from, blockNumber, overrides)
+
+	arrayfiedCalls, _, err := Calls(calls).ToArray(false, true)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+
+	return m.aggregate(
+		ctx,
+		Calls(calls),
+		client,
+		from,
+		blockNumber,
+		isCall,
+		overrides,
+		nil,
+		"tryAggregate(bool,(address,bytes)[])",
+		[]string{"(bool,bytes)[]"},
+		requireSuccess, arrayfiedCalls,
+	)
+}
+
+// BlockAndAggregate is like Aggregate, also stamping Result.Block with the block number and hash.
+func (m *MultiCall3) BlockAndAggregate(
+	calls []Call, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	return m.BlockAndAggregateContext(context.Background(), calls, client, from, blockNumber, isCall, overrides)
+}
+
+// BlockAndAggregateContext is like BlockAndAggregate but issues every RPC with ctx.
+func (m *MultiCall3) BlockAndAggregateContext(
+	ctx context.Context, calls []Call, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.Defaults.resolve(from, blockNumber, overrides)
+
+	arrayfiedCalls, _, err := Calls(calls).ToArray(false, true)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+
+	return m.aggregate(
+		ctx,
+		Calls(calls),
+		client,
+		from,
+		blockNumber,
+		isCall,
+		overrides,
+		nil,
+		"blockAndAggregate((address,bytes)[])",
+		[]string{"uint256", "bytes32", "(bool,bytes)[]"},
+		arrayfiedCalls,
+	)
+}
+
+// TryBlockAndAggregate is like TryAggregate, also stamping Result.Block with the block number and hash.
+func (m *MultiCall3) TryBlockAndAggregate(
+	calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	return m.TryBlockAndAggregateContext(context.Background(), calls, requireSuccess, client, from, blockNumber, isCall, overrides)
+}
+
+// TryBlockAndAggregateContext is like TryBlockAndAggregate but issues every RPC with ctx.
+func (m *MultiCall3) TryBlockAndAggregateContext(
+	ctx context.Context, calls []Call, requireSuccess bool, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.Defaults.resolve(from, blockNumber, overrides)
+
+	arrayfiedCalls, _, err := Calls(calls).ToArray(false, true)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+
+	return m.aggregate(
+		ctx,
+		Calls(calls),
+		client,
+		from,
+		blockNumber,
+		isCall,
+		overrides,
+		nil,
+		"tryBlockAndAggregate(bool,(address,bytes)[])",
+		[]string{"uint256", "bytes32", "(bool,bytes)[]"},
+		requireSuccess, arrayfiedCalls,
+	)
+}
+
+// Aggregate3 runs calls through aggregate3, where only calls with RequireSuccess set revert the batch.
+func (m *MultiCall3) Aggregate3(
+	calls []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	return m.Aggregate3Context(context.Background(), calls, client, from, blockNumber, isCall, overrides)
+}
+
+// Aggregate3Context is like Aggregate3 but issues every RPC with ctx.
+func (m *MultiCall3) Aggregate3Context(
+	ctx context.Context, calls []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.Defaults.resolve(from, blockNumber, overrides)
+
+	arrayfiedCalls, _, err := CallsWithFailure(calls).ToArray(false, true)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+
+	return m.aggregate(
+		ctx,
+		CallsWithFailure(calls),
+		client,
+		from,
+		blockNumber,
+		isCall,
+		overrides,
+		nil,
+		"aggregate3((address,bool,bytes)[])",
+		[]string{"(bool,bytes)[]"},
+		arrayfiedCalls,
+	)
+}
+
+// Aggregate3Value is like Aggregate3 but forwards each call's value, sending their sum along.
+func (m *MultiCall3) Aggregate3Value(
+	calls []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	return m.Aggregate3ValueContext(context.Background(), calls, client, from, blockNumber, isCall, overrides)
+}
+
+// Aggregate3ValueContext is like Aggregate3Value but issues every RPC with ctx.
+func (m *MultiCall3) Aggregate3ValueContext(
+	ctx context.Context, calls []CallWithFailure, client Backend, from *common.Address, blockNumber *big.Int, isCall bool, overrides StateOverride,
+) Result {
+	from, blockNumber, overrides = m.Defaults.resolve(from, blockNumber, overrides)
+
+	arrayfiedCalls, msgValue, err := CallsWithFailure(calls).ToArray(true, true)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+
+	return m.aggregate(
+		ctx,
+		CallsWithFailure(calls),
+		client,
+		from,
+		blockNumber,
+		isCall,
+		overrides,
+		msgValue,
+		"aggregate3Value((address,bool,uint256,bytes)[])",
+		[]string{"(bool,bytes)[]"},
+		arrayfiedCalls,
+	)
+}
+
+// Balances returns the ether balance of every address, batched through aggregate3
+// calls to getEthBalance. Result.Result holds a single []any of *big.Int.
+func (m *MultiCall3) Balances(addresses []*common.Address, client Backend, blockNumber *big.Int) Result {
+	return m.BalancesContext(context.Background(), addresses, client, blockNumber)
+}
+
+// BalancesContext is like Balances but issues every RPC with ctx.
+func (m *MultiCall3) BalancesContext(
+	ctx context.Context, addresses []*common.Address, client Backend, blockNumber *big.Int,
+) Result {
+	calls := make(CallsWithFailure, len(addresses))
+	for i, address := range addresses {
+		calls[i] = NewCallWithFailure(
+			*m.ContractAddress, "getEthBalance(address)", []any{address}, nil, []string{"uint256"}, nil, true,
+		)
+	}
+
+	result := m.Aggregate3Context(ctx, calls, client, nil, blockNumber, true, nil)
+	if result.Error != nil {
+		return result
+	}
+
+	balances := make([]any, len(addresses))
+	for i, callResult := range result.CallResults() {
+		balances[i] = callResult.Decoded[0]
+	}
+	result.Result = []any{balances}
+
+	return result
+}
+
+// GetEthBalance returns the ether balance of address.
+func (m *MultiCall3) GetEthBalance(address common.Address, client Backend, blockNumber *big.Int) Result {
+	return m.GetEthBalanceContext(context.Background(), address, client, blockNumber)
+}
+
+func (m *MultiCall3) GetEthBalanceContext(ctx context.Context, address common.Address, client Backend, blockNumber *big.Int) Result {
+	return m.get(ctx, client, blockNumber, "getEthBalance(address)", "uint256", &address)
+}
+
+// GetBlockHash returns the hash of block number, zero outside the last 256 blocks.
+func (m *MultiCall3) GetBlockHash(number *big.Int, client Backend, blockNumber *big.Int) Result {
+	return m.GetBlockHashContext(context.Background(), number, client, blockNumber)
+}
+
+func (m *MultiCall3) GetBlockHashContext(ctx context.Context, number *big.Int, client Backend, blockNumber *big.Int) Result {
+	return m.get(ctx, client, blockNumber, "getBlockHash(uint256)", "bytes32", number)
+}
+
+func (m *MultiCall3) GetBlockNumber(client Backend, blockNumber *big.Int) Result {
+	return m.GetBlockNumberContext(context.Background(), client, blockNumber)
+}
+
+func (m *MultiCall3) GetBlockNumberContext(ctx context.Context, client Backend, blockNumber *big.Int) Result {
+	return m.get(ctx, client, blockNumber, "getBlockNumber()", "uint256")
+}
+
+func (m *MultiCall3) GetChainId(client Backend, blockNumber *big.Int) Result {
+	return m.GetChainIdContext(context.Background(), client, blockNumber)
+}
+
+func (m *MultiCall3) GetChainIdContext(ctx context.Context, client Backend, blockNumber *big.Int) Result {
+	return m.get(ctx, client, blockNumber, "getChainId()", "uint256")
+}
+
+func (m *MultiCall3) GetBasefee(client Backend, blockNumber *big.Int) Result {
+	return m.GetBasefeeContext(context.Background(), client, blockNumber)
+}
+
+func (m *MultiCall3) GetBasefeeContext(ctx context.Context, client Backend, blockNumber *big.Int) Result {
+	return m.get(ctx, client, blockNumber, "getBasefee()", "uint256")
+}
+
+func (m *MultiCall3) GetLastBlockHash(client Backend, blockNumber *big.Int) Result {
+	return m.GetLastBlockHashContext(context.Background(), client, blockNumber)
+}
+
+func (m *MultiCall3) GetLastBlockHashContext(ctx context.Context, client Backend, blockNumber *big.Int) Result {
+	return m.get(ctx, client, blockNumber, "getLastBlockHash()", "bytes32")
+}
+
+func (m *MultiCall3) GetCurrentBlockCoinbase(client Backend, blockNumber *big.Int) Result {
+	return m.GetCurrentBlockCoinbaseContext(context.Background(), client, blockNumber)
+}
+
+func (m *MultiCall3) GetCurrentBlockCoinbaseContext(ctx context.Context, client Backend, blockNumber *big.Int) Result {
+	return m.get(ctx, client, blockNumber, "getCurrentBlockCoinbase()", "address")
+}
+
+func (m *MultiCall3) GetCurrentBlockDifficulty(client Backend, blockNumber *big.Int) Result {
+	return m.GetCurrentBlockDifficultyContext(context.Background(), client, blockNumber)
+}
+
+func (m *MultiCall3) GetCurrentBlockDifficultyContext(ctx context.Context, client Backend, blockNumber *big.Int) Result {
+	return m.get(ctx, client, blockNumber, "getCurrentBlockDifficulty()", "uint256")
+}
+
+func (m *MultiCall3) GetCurrentBlockGasLimit(client Backend, blockNumber *big.Int) Result {
+	return m.GetCurrentBlockGasLimitContext(context.Background(), client, blockNumber)
+}
+
+func (m *MultiCall3) GetCurrentBlockGasLimitContext(ctx context.Context, client Backend, blockNumber *big.Int) Result {
+	return m.get(ctx, client, blockNumber, "getCurrentBlockGasLimit()", "uint256")
+}
+
+func (m *MultiCall3) GetCurrentBlockTimestamp(client Backend, blockNumber *big.Int) Result {
+	return m.GetCurrentBlockTimestampContext(context.Background(), client, blockNumber)
+}
+
+func (m *MultiCall3) GetCurrentBlockTimestampContext(ctx context.Context, client Backend, blockNumber *big.Int) Result {
+	return m.get(ctx, client, blockNumber, "getCurrentBlockTimestamp()", "uint256")
+}
+
+// aggregate runs one of the aggregate functions and turns its outputs into
+// CallResults. The call entries are the last output; functions returning more
+// lead with the block number and, when there are three, the block hash.
+func (m *MultiCall3) aggregate(
+	ctx context.Context, calls CallsInterface, client Backend, from *common.Address, blockNumber *big.Int,
+	isCall bool, overrides StateOverride, value *big.Int, funcSignature string, returnTypes []string, params ...any,
+) Result {
+	decoded, success, txOrCall, err := m.execute(
+		ctx, calls, client, from, blockNumber, isCall, overrides, value, funcSignature, returnTypes, params...,
+	)
+	if err != nil {
+		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+	}
+
+	entries, ok := decoded[len(decoded)-1].([]any)
+	if !ok {
+		return Result{Success: false, Error: fmt.Errorf("unexpected %s result: %v", funcSignature, decoded), TxOrCall: txOrCall}
+	}
+
+	callResults, err := newCallResults(entries, calls)
+	if err != nil {
+		return Result{Success: false, Error: fmt.Errorf("error decoding call result: %w", err), TxOrCall: txOrCall}
+	}
+
+	result := Result{Success: success, Result: callResults, TxOrCall: txOrCall}
+	// for transactions the outputs come from the preflight call, not the mined block
+	if isCall && len(decoded) > 1 {
+		result.Block = &BlockStamp{Number: decoded[0].(*big.Int)}
+		result.TxOrCall.BlockNumber = result.Block.Number
+	}
+
+	return result
+}
+
+func (m *MultiCall3) get(
+	ctx context.Context, client Backend, blockNumber *big.Int, funcSignature string, returnType string, params ...any,
+) Result {
+	blockNumber = m.Defaults.resolveBlockNumber(blockNumber)
+
+	decoded, _, txOrCall, err := m.execute(
+		ctx, nil, client, nil, blockNumber, true, nil, nil, funcSignature, []string{returnType}, params...,
+	)
+	if err != nil {
+		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+	}
+
+	return Result{Success: true, Result: decoded, TxOrCall: txOrCall}
+}
+
+// execute runs funcSignature(params...) on the contract, as an eth_call when
+// isCall is set and as a transaction otherwise, and decodes its outputs. The
+// outputs of a transaction come from a preflight call from the signer; success
+// reports its receipt status.
+func (m *MultiCall3) execute(
+	ctx context.Context, calls CallsInterface, client Backend, from *common.Address, blockNumber *big.Int,
+	isCall bool, overrides StateOverride, value *big.Int, funcSignature string, returnTypes []string, params ...any,
+) ([]any, bool, TxOrCall, error) {
+	callData, err := abi.EncodeWithSignature(funcSignature, params...)
+	if err != nil {
+		return nil, false, TxOrCall{}, err
+	}
+
+	if isCall {
+		if !deployedAt(m.deployment, blockNumber) {
+			return nil, false, TxOrCall{}, fmt.Errorf(
+				"%w: %s is deployed from block %v, not at block %v",
+				ErrNoMulticallContract, m.ContractAddress.Hex(), m.deployment, blockNumber,
+			)
+		}
+
+		encodedResult, call, err := readContract(ctx, client, from, m.ContractAddress, value, callData, blockNumber, overrides)
+		if err != nil {
+			return nil, false, FromCallToTxOrCall(call, blockNumber, overrides), decodeMultiCallError(err, calls)
+		}
+
+		if blockNumber == nil {
+			latest, err := client.BlockNumber(ctx)
+			if err != nil {
+				return nil, false, FromCallToTxOrCall(call, blockNumber, overrides), err
+			}
+			blockNumber = new(big.Int).SetUint64(latest)
+		}
+
+		decoded, err := safeDecode(returnTypes, encodedResult)
+		if err != nil {
+			return nil, false, FromCallToTxOrCall(call, blockNumber, overrides), fmt.Errorf("error decoding call result: %w", err)
+		}
+
+		return decoded, true, FromCallToTxOrCall(call, blockNumber, overrides), nil
+	}
+
+	if m.Signer == nil {
+		return nil, false, TxOrCall{}, ErrNoSigner
+	}
+	signer := *m.Signer
+
+	chainId, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, false, TxOrCall{}, err
+	}
+
+	tx, err := createTransaction(ctx, client, chainId, signer.GetAddress(), m.ContractAddress, value, callData, m.Fees, m.Nonces)
+	if err != nil {
+		return nil, false, TxOrCall{}, err
+	}
+
+	signedTx, err := signer.SignTx(tx, chainId)
+	if err != nil {
+		m.Nonces.Release(*signer.GetAddress(), tx.Nonce())
+		return nil, false, FromTxToTxOrCall(tx, *signer.GetAddress(), nil, nil), err
+	}
+
+	encodedResult, _, err := readContract(ctx, client, signer.GetAddress(), m.ContractAddress, value, callData, nil, nil)
+	if err != nil {
+		m.Nonces.Release(*signer.GetAddress(), tx.Nonce())
+		return nil, false, FromTxToTxOrCall(signedTx, *signer.GetAddress(), nil, nil), decodeMultiCallError(err, calls)
+	}
+
+	receipt, signedTx, hashes, err := sendSignedTransaction(ctx, client, signedTx, m.Replacement, signWith(signer, chainId), m.Nonces)
+	if err != nil {
+		txOrCall := FromTxToTxOrCall(signedTx, *signer.GetAddress(), nil, nil)
+		txOrCall.Hashes = hashes
+		return nil, false, txOrCall, fmt.Errorf("error sending signed transaction: %w", err)
+	}
+	txOrCall := FromTxToTxOrCall(signedTx, *signer.GetAddress(), receipt.BlockNumber, nil)
+	txOrCall.Hashes = hashes
+
+	decoded, err := safeDecode(returnTypes, encodedResult)
+	if err != nil {
+		return nil, false, txOrCall, fmt.Errorf("error decoding call result: %w", err)
+	}
+
+	return decoded, receipt.Status == types.ReceiptStatusSuccessful, txOrCall, nil
+}
@@ -0,0 +1,132 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/omnes-tech/abi"
+)
+
+// multicall3Backend serves a Multicall3 at MULTICALL3_ADDRESS, answering each
+// function with canned outputs and recording the last call data it received.
+func multicall3Backend(t *testing.T, outputs map[string][]byte) (*fakeBackend, *[]byte) {
+	t.Helper()
+
+	var lastData []byte
+	backend := &fakeBackend{
+		blockNumber: 100,
+		code:        map[common.Address][]byte{MULTICALL3_ADDRESS: {0x60, 0x80}},
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			call := args[0].(CallArgs)
+			lastData = call.Data
+			for signature, output := range outputs {
+				if bytes.HasPrefix(call.Data, abi.EncodeSignature(signature)) {
+					*result.(*hexutil.Bytes) = output
+					return nil
+				}
+			}
+			t.Fatalf("unexpected call data %x", call.Data)
+			return nil
+		},
+	}
+
+	return backend, &lastData
+}
+
+func TestNewMultiCall3(t *testing.T) {
+	if _, err := NewMultiCall3(&fakeBackend{}, nil); !errors.Is(err, ErrNoMulticallContract) {
+		t.Fatalf("err = %v, want ErrNoMulticallContract", err)
+	}
+
+	backend, _ := multicall3Backend(t, nil)
+	m, err := NewMultiCall3(backend, nil)
+	if err != nil {
+		t.Fatalf("NewMultiCall3: %v", err)
+	}
+	if *m.ContractAddress != MULTICALL3_ADDRESS {
+		t.Fatalf("ContractAddress = %s, want %s", m.ContractAddress, MULTICALL3_ADDRESS)
+	}
+}
+
+func TestMultiCall3(t *testing.T) {
+	okData, _ := abi.Encode([]string{"uint256"}, big.NewInt(7))
+	revertData, _ := abi.EncodeWithSignature("Error(string)", "nope")
+	tryOutput, _ := abi.Encode([]string{"(bool,bytes)[]"}, []any{[]any{true, okData}, []any{false, revertData}})
+	hash := common.HexToHash("0xabcdef")
+	blockOutput, _ := abi.Encode(
+		[]string{"uint256", "bytes32", "(bool,bytes)[]"},
+		big.NewInt(99), hash.Bytes(), []any{[]any{true, okData}, []any{true, okData}},
+	)
+	balanceOutput, _ := abi.Encode([]string{"uint256"}, big.NewInt(5))
+
+	backend, lastData := multicall3Backend(t, map[string][]byte{
+		"aggregate3((address,bool,bytes)[])":   tryOutput,
+		"blockAndAggregate((address,bytes)[])": blockOutput,
+		"getEthBalance(address)":               balanceOutput,
+	})
+	m, err := NewMultiCall3(backend, nil)
+	if err != nil {
+		t.Fatalf("NewMultiCall3: %v", err)
+	}
+
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCallsWithFailure(
+		[]common.Address{target, target}, []string{"a()", "b()"}, nil, nil,
+		[][]string{{"uint256"}, {"uint256"}}, nil, []bool{true, false},
+	)
+
+	t.Run("aggregate3", func(t *testing.T) {
+		result := m.Aggregate3(calls, backend, nil, nil, true, nil)
+		if !result.Success {
+			t.Fatalf("Aggregate3: %v", result.Error)
+		}
+
+		decoded, err := abi.Decode([]string{"(address,bool,bytes)[]"}, (*lastData)[4:])
+		if err != nil {
+			t.Fatalf("decode call data: %v", err)
+		}
+		sent := decoded[0].([]any)
+		if sent[0].([]any)[1].(bool) || !sent[1].([]any)[1].(bool) {
+			t.Fatalf("allowFailure flags = %v, want [false true]", sent)
+		}
+
+		callResults := result.CallResults()
+		if len(callResults) != 2 || callResults[0].Decoded[0].(*big.Int).Int64() != 7 || callResults[1].Revert.Reason != "nope" {
+			t.Fatalf("CallResults = %v", callResults)
+		}
+		if result.Block != nil || result.TxOrCall.BlockNumber.Int64() != 100 {
+			t.Fatalf("Block = %v, BlockNumber = %v", result.Block, result.TxOrCall.BlockNumber)
+		}
+	})
+
+	t.Run("block and aggregate", func(t *testing.T) {
+		result := m.BlockAndAggregate(calls.toCalls(), backend, nil, nil, true, nil)
+		if !result.Success {
+			t.Fatalf("BlockAndAggregate: %v", result.Error)
+		}
+		if result.Block == nil || result.Block.Number.Int64() != 99 {
+			t.Fatalf("Block = %+v", result.Block)
+		}
+		if result.CallCount() != 2 {
+			t.Fatalf("CallCount = %d, want 2", result.CallCount())
+		}
+	})
+
+	t.Run("getters", func(t *testing.T) {
+		result := m.GetEthBalance(target, backend, nil)
+		if !result.Success || result.Result.([]any)[0].(*big.Int).Int64() != 5 {
+			t.Fatalf("GetEthBalance = %v, %v", result.Result, result.Error)
+		}
+	})
+
+	t.Run("transactions need a signer", func(t *testing.T) {
+		if result := m.Aggregate3(calls, backend, nil, nil, false, nil); !errors.Is(result.Error, ErrNoSigner) {
+			t.Fatalf("Error = %v, want ErrNoSigner", result.Error)
+		}
+	})
+}
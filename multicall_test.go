@@ -5,13 +5,12 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/omnes-tech/multicall"
 )
 
-func ExampleNewClient() {
+func ExampleNewMultiCall() {
 	rpc := "https://eth-mainnet.g.alchemy.com/public"
-	client, err := ethclient.Dial(rpc)
+	client, err := multicall.Dial(rpc)
 	if err != nil {
 		panic(err)
 	}
@@ -28,7 +27,7 @@ func ExampleNewClient() {
 
 func ExampleMultiCall_SimulateCall() {
 	rpc := "https://eth-mainnet.g.alchemy.com/public"
-	client, err := ethclient.Dial(rpc)
+	client, err := multicall.Dial(rpc)
 	if err != nil {
 		panic(err)
 	}
@@ -56,12 +55,12 @@ func ExampleMultiCall_SimulateCall() {
 
 	results.Print(false)
 
-	// Output: Success: true, Result: [[false 0x +10713] [false 0x +8213]]
+	// Output: Success: true, Result: [{Index: 0, Success: false, ReturnData: 0x, GasUsed: 10713, Revert: execution reverted} {Index: 1, Success: false, ReturnData: 0x, GasUsed: 8213, Revert: execution reverted}]
 }
 
 func ExampleMultiCall_AggregateStatic() {
 	rpc := "https://eth-mainnet.g.alchemy.com/public"
-	client, err := ethclient.Dial(rpc)
+	client, err := multicall.Dial(rpc)
 	if err != nil {
 		panic(err)
 	}
@@ -96,12 +95,12 @@ func ExampleMultiCall_AggregateStatic() {
 
 	results.Print(false)
 
-	// Output: Success: true, Result: [[+1088879090944244880639] [+1088879090944244880639]]
+	// Output: Success: true, Result: [{Index: 0, Success: true, Decoded: [1088879090944244880639]} {Index: 1, Success: true, Decoded: [1088879090944244880639]}]
 }
 
 func ExampleMultiCall_TryAggregateStatic() {
 	rpc := "https://eth-mainnet.g.alchemy.com/public"
-	client, err := ethclient.Dial(rpc)
+	client, err := multicall.Dial(rpc)
 	if err != nil {
 		panic(err)
 	}
@@ -136,12 +135,12 @@ func ExampleMultiCall_TryAggregateStatic() {
 
 	results.Print(false)
 
-	// Output: Success: true, Result: [[true +1088879090944244880639] [true +1088879090944244880639]]
+	// Output: Success: true, Result: [{Index: 0, Success: true, Decoded: [1088879090944244880639]} {Index: 1, Success: true, Decoded: [1088879090944244880639]}]
 }
 
 func ExampleMultiCall_TryAggregateStatic3() {
 	rpc := "https://eth-mainnet.g.alchemy.com/public"
-	client, err := ethclient.Dial(rpc)
+	client, err := multicall.Dial(rpc)
 	if err != nil {
 		panic(err)
 	}
@@ -177,12 +176,12 @@ func ExampleMultiCall_TryAggregateStatic3() {
 
 	results.Print(false)
 
-	// Output: Success: true, Result: [[true +1088879090944244880639] [true +1088879090944244880639]]
+	// Output: Success: true, Result: [{Index: 0, Success: true, Decoded: [1088879090944244880639]} {Index: 1, Success: true, Decoded: [1088879090944244880639]}]
 }
 
 func ExampleMultiCall_CodeLengths() {
 	rpc := "https://eth-mainnet.g.alchemy.com/public"
-	client, err := ethclient.Dial(rpc)
+	client, err := multicall.Dial(rpc)
 	if err != nil {
 		panic(err)
 	}
@@ -208,7 +207,7 @@ func ExampleMultiCall_CodeLengths() {
 
 func ExampleMultiCall_Balances() {
 	rpc := "https://eth-mainnet.g.alchemy.com/public"
-	client, err := ethclient.Dial(rpc)
+	client, err := multicall.Dial(rpc)
 	if err != nil {
 		panic(err)
 	}
@@ -232,7 +231,7 @@ func ExampleMultiCall_Balances() {
 
 func ExampleMultiCall_AddressesData() {
 	rpc := "https://eth-mainnet.g.alchemy.com/public"
-	client, err := ethclient.Dial(rpc)
+	client, err := multicall.Dial(rpc)
 	if err != nil {
 		panic(err)
 	}
@@ -256,7 +255,7 @@ func ExampleMultiCall_AddressesData() {
 
 func ExampleMultiCall_ChainData() {
 	rpc := "https://eth-mainnet.g.alchemy.com/public"
-	client, err := ethclient.Dial(rpc)
+	client, err := multicall.Dial(rpc)
 	if err != nil {
 		panic(err)
 	}
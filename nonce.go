@@ -0,0 +1,142 @@
+package multicall
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// NonceManager allocates transaction nonces locally, so concurrent writes from
+// the same signer get distinct nonces instead of all reading the same pending
+// nonce. It is safe for concurrent use and must only be used with one chain. A
+// nil NonceManager reads the pending nonce for every transaction.
+type NonceManager struct {
+	mu       sync.Mutex
+	accounts map[common.Address]*accountNonces
+}
+
+type accountNonces struct {
+	next uint64
+	// released holds the nonces below next given back by failed broadcasts, in
+	// increasing order.
+	released []uint64
+}
+
+func NewNonceManager() *NonceManager {
+	return &NonceManager{accounts: make(map[common.Address]*accountNonces)}
+}
+
+// Next allocates the nonce of the next transaction of account: the lowest
+// released nonce, or the one following the last allocated. The first nonce is
+// the pending nonce of account, read again after Resync.
+func (n *NonceManager) Next(ctx context.Context, client Backend, account common.Address) (uint64, error) {
+	return n.NextConsecutive(ctx, client, account, 1)
+}
+
+// NextConsecutive is like Next but allocates count consecutive nonces and
+// returns the first one, e.g. for a transaction carrying an authorization signed
+// by its own sender. Each of them is released on its own.
+func (n *NonceManager) NextConsecutive(ctx context.Context, client Backend, account common.Address, count uint64) (uint64, error) {
+	if n == nil {
+		return client.PendingNonceAt(ctx, account)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	nonces, ok := n.accounts[account]
+	if !ok {
+		pending, err := client.PendingNonceAt(ctx, account)
+		if err != nil {
+			return 0, err
+		}
+		nonces = &accountNonces{next: pending}
+		n.accounts[account] = nonces
+	}
+
+	// the lowest run of count released nonces
+	for i := 0; i+int(count) <= len(nonces.released); i++ {
+		first := nonces.released[i]
+		if nonces.released[i+int(count)-1] == first+count-1 {
+			nonces.released = append(nonces.released[:i], nonces.released[i+int(count):]...)
+			return first, nil
+		}
+	}
+
+	first := nonces.next
+	nonces.next += count
+	return first, nil
+}
+
+// Release gives back nonce, allocated by Next, when its transaction was not
+// broadcast, so the gap it would leave is filled by the next transaction.
+func (n *NonceManager) Release(account common.Address, nonce uint64) {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	nonces, ok := n.accounts[account]
+	if !ok || nonce >= nonces.next {
+		return
+	}
+
+	i := sort.Search(len(nonces.released), func(i int) bool { return nonces.released[i] >= nonce })
+	if i < len(nonces.released) && nonces.released[i] == nonce {
+		return
+	}
+	nonces.released = append(nonces.released, 0)
+	copy(nonces.released[i+1:], nonces.released[i:])
+	nonces.released[i] = nonce
+
+	// released nonces at the end are simply allocated again
+	for len(nonces.released) > 0 && nonces.released[len(nonces.released)-1] == nonces.next-1 {
+		nonces.released = nonces.released[:len(nonces.released)-1]
+		nonces.next--
+	}
+}
+
+// Resync forgets the nonces of account, the next one being read from the
+// pending state again.
+func (n *NonceManager) Resync(account common.Address) {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.accounts, account)
+}
+
+// broadcastFailed settles the nonces of a transaction after it was rejected with
+// err: the local nonces are resynced when the node already has a transaction
+// with that nonce, and the nonces are released otherwise.
+func (n *NonceManager) broadcastFailed(account common.Address, err error, nonces ...uint64) {
+	message := err.Error()
+	if strings.Contains(message, "nonce too low") || strings.Contains(message, "already known") {
+		n.Resync(account)
+		return
+	}
+	for _, nonce := range nonces {
+		n.Release(account, nonce)
+	}
+}
+
+// txNonces returns the nonces of from used by tx: its own and those of the
+// authorizations from signed.
+func txNonces(tx *types.Transaction, from common.Address) []uint64 {
+	nonces := []uint64{tx.Nonce()}
+	for _, auth := range tx.SetCodeAuthorizations() {
+		if authority, err := auth.Authority(); err == nil && authority == from {
+			nonces = append(nonces, auth.Nonce)
+		}
+	}
+	return nonces
+}
@@ -0,0 +1,149 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNonceManager(t *testing.T) {
+	ctx := context.Background()
+	account := common.HexToAddress("0x000000000000000000000000000000000000a11c")
+	backend := &fakeBackend{gasPrice: big.NewInt(1), nonce: 5}
+
+	next := func(nonces *NonceManager) uint64 {
+		t.Helper()
+		nonce, err := nonces.Next(ctx, backend, account)
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		return nonce
+	}
+
+	t.Run("concurrent allocations are distinct", func(t *testing.T) {
+		nonces := NewNonceManager()
+
+		var mu sync.Mutex
+		seen := make(map[uint64]bool)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				nonce, err := nonces.Next(ctx, backend, account)
+				if err != nil {
+					t.Errorf("Next: %v", err)
+					return
+				}
+				mu.Lock()
+				seen[nonce] = true
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		for nonce := uint64(5); nonce < 25; nonce++ {
+			if !seen[nonce] {
+				t.Fatalf("nonce %d was not allocated: %v", nonce, seen)
+			}
+		}
+	})
+
+	t.Run("released nonces fill gaps", func(t *testing.T) {
+		nonces := NewNonceManager()
+		for i := 0; i < 4; i++ {
+			next(nonces) // 5 to 8
+		}
+
+		nonces.Release(account, 6)
+		if got := next(nonces); got != 6 {
+			t.Fatalf("Next = %d, want the released 6", got)
+		}
+
+		nonces.Release(account, 7)
+		nonces.Release(account, 8)
+		if got := next(nonces); got != 7 {
+			t.Fatalf("Next = %d, want 7 after releasing the last nonces", got)
+		}
+		if got := next(nonces); got != 8 {
+			t.Fatalf("Next = %d, want 8", got)
+		}
+		if got := next(nonces); got != 9 {
+			t.Fatalf("Next = %d, want 9", got)
+		}
+	})
+
+	t.Run("consecutive nonces", func(t *testing.T) {
+		nonces := NewNonceManager()
+		for i := 0; i < 4; i++ {
+			next(nonces) // 5 to 8
+		}
+		next(nonces) // 9
+
+		nonces.Release(account, 6)
+		nonces.Release(account, 8)
+		if first, err := nonces.NextConsecutive(ctx, backend, account, 2); err != nil || first != 10 {
+			t.Fatalf("NextConsecutive = %d (%v), want 10 past the released 6 and 8", first, err)
+		}
+
+		nonces.Release(account, 7)
+		if first, err := nonces.NextConsecutive(ctx, backend, account, 2); err != nil || first != 6 {
+			t.Fatalf("NextConsecutive = %d (%v), want the released 6 and 7", first, err)
+		}
+		if got := next(nonces); got != 8 {
+			t.Fatalf("Next = %d, want the released 8", got)
+		}
+	})
+
+	t.Run("broadcast errors", func(t *testing.T) {
+		nonces := NewNonceManager()
+		next(nonces)
+		next(nonces) // 6
+
+		nonces.broadcastFailed(account, errors.New("insufficient funds for gas * price + value"), 6)
+		if got := next(nonces); got != 6 {
+			t.Fatalf("Next = %d, want the released 6", got)
+		}
+
+		backend.nonce = 9
+		defer func() { backend.nonce = 5 }()
+		nonces.broadcastFailed(account, errors.New("nonce too low: next nonce 9, tx nonce 6"), 6)
+		if got := next(nonces); got != 9 {
+			t.Fatalf("Next = %d, want the resynced 9", got)
+		}
+	})
+
+	t.Run("nil manager reads the pending nonce", func(t *testing.T) {
+		var nonces *NonceManager
+		if next(nonces) != 5 || next(nonces) != 5 {
+			t.Fatalf("want the pending nonce on every call")
+		}
+		nonces.Release(account, 5)
+		nonces.Resync(account)
+	})
+}
+
+func TestNewMultiCall_SharedNonceManager(t *testing.T) {
+	backend := &fakeBackend{code: map[common.Address][]byte{OMNES_MULTICALL_ADDRESS: {0x60, 0x80}}}
+
+	m, err := NewMultiCall(backend, nil)
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+	if m.Nonces == nil {
+		t.Fatalf("Nonces = nil, want a NonceManager of its own")
+	}
+
+	nonces := NewNonceManager()
+	shared, err := NewMultiCall(backend, nil, WithNonceManager(nonces))
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+	if shared.Nonces != nonces {
+		t.Fatalf("Nonces was not shared")
+	}
+}
@@ -0,0 +1,144 @@
+package multicall
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Logger is the logging interface used by MultiCall. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(string, ...any) {}
+
+type mode uint8
+
+const (
+	autoMode mode = iota
+	deployedMode
+	deploylessMode
+)
+
+type options struct {
+	contractAddress *common.Address
+	mode            mode
+	logger          Logger
+	defaults        Overrides
+	chunking        ChunkOptions
+	detector        *Detector
+	delegation      *Delegation
+	fees            FeeStrategy
+	replacement     ReplacementPolicy
+	nonces          *NonceManager
+}
+
+// Option configures a MultiCall built by NewMultiCall.
+type Option func(*options)
+
+// WithContractAddress looks for the multicall contract at address instead of
+// OMNES_MULTICALL_ADDRESS, e.g. on forks or private chains with their own deployment.
+func WithContractAddress(address common.Address) Option {
+	return func(o *options) {
+		o.contractAddress = &address
+	}
+}
+
+// WithDeployedMode uses the contract address without probing the chain for its code.
+func WithDeployedMode() Option {
+	return func(o *options) {
+		o.mode = deployedMode
+	}
+}
+
+// WithDeploylessMode always uses the deployless bytecode without probing the chain.
+func WithDeploylessMode() Option {
+	return func(o *options) {
+		o.mode = deploylessMode
+	}
+}
+
+// WithLogger replaces the standard logger. A nil logger discards all output.
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		if logger == nil {
+			logger = nopLogger{}
+		}
+		o.logger = logger
+	}
+}
+
+// WithFrom sets the sender used by calls that do not specify one.
+func WithFrom(from common.Address) Option {
+	return func(o *options) {
+		o.defaults.From = &from
+	}
+}
+
+// WithBlockNumber pins calls that do not specify a block to blockNumber.
+func WithBlockNumber(blockNumber *big.Int) Option {
+	return func(o *options) {
+		o.defaults.BlockNumber = blockNumber
+	}
+}
+
+// WithStateOverride applies overrides to every call. Per-call overrides take
+// precedence for the accounts they touch.
+func WithStateOverride(overrides StateOverride) Option {
+	return func(o *options) {
+		o.defaults.StateOverrides = overrides
+	}
+}
+
+// WithChunking splits oversized static and simulation batches according to chunking.
+func WithChunking(chunking ChunkOptions) Option {
+	return func(o *options) {
+		o.chunking = chunking
+	}
+}
+
+// WithDetector probes the chain through detector, which caches the outcome per
+// chain ID, instead of only checking the Omnes contract. Operations the chain
+// cannot serve through the Omnes contract are then routed to Multicall3,
+// Multicall2 or deployless calls, whichever the capabilities favor.
+func WithDetector(detector *Detector) Option {
+	return func(o *options) {
+		o.detector = detector
+	}
+}
+
+// WithDelegation makes the aggregate write methods execute the calls from the signer's EOA,
+// delegated to delegation.Implementation with an EIP-7702 SetCode transaction,
+// instead of from the multicall contract.
+func WithDelegation(delegation Delegation) Option {
+	return func(o *options) {
+		o.delegation = &delegation
+	}
+}
+
+// WithFeeStrategy prices transactions with fees instead of FeeStrategyStandard.
+func WithFeeStrategy(fees FeeStrategy) Option {
+	return func(o *options) {
+		o.fees = fees
+	}
+}
+
+// WithReplacementPolicy replaces transactions that are not mined in time
+// according to policy.
+func WithReplacementPolicy(policy ReplacementPolicy) Option {
+	return func(o *options) {
+		o.replacement = policy
+	}
+}
+
+// WithNonceManager allocates nonces with nonces, e.g. to share them with other
+// MultiCall instances sending from the same signer, instead of a NonceManager
+// of the MultiCall's own.
+func WithNonceManager(nonces *NonceManager) Option {
+	return func(o *options) {
+		o.nonces = nonces
+	}
+}
@@ -0,0 +1,95 @@
+package multicall
+
+import (
+	"bytes"
+	"log"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNewMultiCall_Options(t *testing.T) {
+	custom := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	t.Run("custom contract address is probed", func(t *testing.T) {
+		registerCode(t, []byte{0x60, 0x80}, "v1")
+		backend := &fakeBackend{code: map[common.Address][]byte{custom: {0x60, 0x80}}}
+
+		m, err := NewMultiCall(backend, nil, WithContractAddress(custom))
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+		if m.ContractAddress == nil || *m.ContractAddress != custom {
+			t.Fatalf("ContractAddress = %v, want %s", m.ContractAddress, custom)
+		}
+	})
+
+	t.Run("forced deployed mode skips probe", func(t *testing.T) {
+		m, err := NewMultiCall(&fakeBackend{}, nil, WithDeployedMode())
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+		if m.ContractAddress == nil || *m.ContractAddress != OMNES_MULTICALL_ADDRESS {
+			t.Fatalf("ContractAddress = %v, want %s", m.ContractAddress, OMNES_MULTICALL_ADDRESS)
+		}
+	})
+
+	t.Run("forced deployless mode ignores deployed code", func(t *testing.T) {
+		backend := &fakeBackend{code: map[common.Address][]byte{OMNES_MULTICALL_ADDRESS: {0x60, 0x80}}}
+
+		m, err := NewMultiCall(backend, nil, WithDeploylessMode())
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+		if !m.IsDeployless() {
+			t.Fatalf("ContractAddress = %v, want nil", m.ContractAddress)
+		}
+	})
+
+	t.Run("fallback is reported to injected logger", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		_, err := NewMultiCall(&fakeBackend{}, nil, WithLogger(log.New(&buf, "", 0)))
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+		if buf.Len() == 0 {
+			t.Fatal("expected deployless fallback to be logged")
+		}
+	})
+
+	t.Run("defaults are applied when unset", func(t *testing.T) {
+		from := common.HexToAddress("0x2222222222222222222222222222222222222222")
+		addr := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+		m, err := NewMultiCall(
+			&fakeBackend{},
+			nil,
+			WithLogger(nil),
+			WithFrom(from),
+			WithBlockNumber(big.NewInt(42)),
+			WithStateOverride(StateOverride{addr: {Balance: balanceHex(1)}}),
+		)
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		gotFrom, gotBlock, gotOverrides := m.resolve(nil, nil, StateOverride{from: {Balance: balanceHex(2)}})
+		if *gotFrom != from {
+			t.Fatalf("from = %s, want %s", gotFrom, from)
+		}
+		if gotBlock.Int64() != 42 {
+			t.Fatalf("blockNumber = %s, want 42", gotBlock)
+		}
+		if len(gotOverrides) != 2 {
+			t.Fatalf("overrides = %v, want default and per-call accounts", gotOverrides)
+		}
+
+		explicit := common.HexToAddress("0x4444444444444444444444444444444444444444")
+		gotFrom, gotBlock, _ = m.resolve(&explicit, big.NewInt(7), nil)
+		if *gotFrom != explicit || gotBlock.Int64() != 7 {
+			t.Fatalf("explicit values overridden: from = %s, blockNumber = %s", gotFrom, gotBlock)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// PendingTx is a transaction sent by an asynchronous write, whose Result is
+// settled in the background once it is mined.
+type PendingTx struct {
+	// Hash is the hash of the broadcast transaction. With a ReplacementPolicy, the
+	// mined transaction may be a replacement, see Hashes and Result.TxOrCall.
+	Hash     common.Hash
+	TxOrCall TxOrCall
+
+	mu     sync.Mutex
+	hashes []common.Hash
+
+	done   chan struct{}
+	result Result
+}
+
+func newPendingTx(tx *types.Transaction, from common.Address) *PendingTx {
+	return &PendingTx{
+		Hash:     tx.Hash(),
+		TxOrCall: FromTxToTxOrCall(tx, from, nil, nil),
+		hashes:   []common.Hash{tx.Hash()},
+		done:     make(chan struct{}),
+	}
+}
+
+// Hashes returns the hashes of the transactions broadcast so far, Hash
+// followed by the replacements sent by the ReplacementPolicy.
+func (p *PendingTx) Hashes() []common.Hash {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.hashes)
+}
+
+// Wait blocks until the transaction is mined and returns the write Result, with
+// the call results decoded from the call made before broadcasting. It returns
+// early with ctx's error when ctx is done, leaving the transaction pending.
+func (p *PendingTx) Wait(ctx context.Context) Result {
+	select {
+	case <-p.done:
+		return p.result
+	case <-ctx.Done():
+		txOrCall := p.TxOrCall
+		txOrCall.Hashes = p.Hashes()
+		return Result{Success: false, Error: ctx.Err(), TxOrCall: txOrCall}
+	}
+}
+
+// Status returns the Result and true once the transaction is mined, or waiting
+// for it failed, and false while it is pending. It never blocks.
+func (p *PendingTx) Status() (Result, bool) {
+	select {
+	case <-p.done:
+		return p.result, true
+	default:
+		return Result{}, false
+	}
+}
+
+// Done is closed once the Result is settled.
+func (p *PendingTx) Done() <-chan struct{} {
+	return p.done
+}
+
+// settle waits, for at most MINING_WAIT_DURATION, for tx or one of its
+// replacements to be mined, and settles the Result built by mined.
+func (p *PendingTx) settle(
+	ctx context.Context, client Backend, tx *types.Transaction, from common.Address, policy ReplacementPolicy,
+	sign func(tx *types.Transaction) (*types.Transaction, error),
+	mined func(receipt *types.Receipt, tx *types.Transaction) Result,
+) {
+	defer close(p.done)
+
+	replaced := func(tx *types.Transaction) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.hashes = append(p.hashes, tx.Hash())
+	}
+	receipt, tx, hashes, err := waitMined(ctx, client, tx, policy, sign, replaced)
+	if err != nil {
+		p.result = Result{
+			Success:  false,
+			Error:    fmt.Errorf("error sending signed transaction: %w", err),
+			TxOrCall: FromTxToTxOrCall(tx, from, nil, nil),
+		}
+	} else {
+		p.result = mined(receipt, tx)
+	}
+	p.result.TxOrCall.Hashes = hashes
+}
+
+// AggregateCallsAsync is like AggregateCalls sending a transaction, but returns
+// as soon as the transaction is broadcast.
+func (m *MultiCall) AggregateCallsAsync(calls []Call, client Backend) (*PendingTx, error) {
+	return m.AggregateCallsAsyncContext(context.Background(), calls, client)
+}
+
+// AggregateCallsAsyncContext is like AggregateCallsAsync but broadcasts with
+// ctx. Waiting for the transaction outlives ctx, see PendingTx.Wait.
+func (m *MultiCall) AggregateCallsAsyncContext(ctx context.Context, calls []Call, client Backend) (*PendingTx, error) {
+	_, blockNumber, overrides := m.resolve(nil, nil, nil)
+	pending, result := m.sendAggregate(
+		ctx, Calls(calls), true, client, blockNumber, overrides,
+		"aggregateCalls((address,bytes,uint256)[])", []string{"bytes[]"}, false,
+	)
+	return pending, result.Error
+}
+
+// TryAggregateCallsAsync is like TryAggregateCalls sending a transaction, but
+// returns as soon as the transaction is broadcast.
+func (m *MultiCall) TryAggregateCallsAsync(calls []Call, requireSuccess bool, client Backend) (*PendingTx, error) {
+	return m.TryAggregateCallsAsyncContext(context.Background(), calls, requireSuccess, client)
+}
+
+// TryAggregateCallsAsyncContext is like TryAggregateCallsAsync but broadcasts with ctx.
+func (m *MultiCall) TryAggregateCallsAsyncContext(
+	ctx context.Context, calls []Call, requireSuccess bool, client Backend,
+) (*PendingTx, error) {
+	_, blockNumber, overrides := m.resolve(nil, nil, nil)
+	pending, result := m.sendAggregate(
+		ctx, Calls(calls), requireSuccess, client, blockNumber, overrides,
+		"tryAggregateCalls((address,bytes,uint256)[],bool)", []string{"(bool,bytes)[]"}, false,
+	)
+	return pending, result.Error
+}
+
+// TryAggregateCalls3Async is like TryAggregateCalls3 sending a transaction, but
+// returns as soon as the transaction is broadcast.
+func (m *MultiCall) TryAggregateCalls3Async(calls []CallWithFailure, client Backend) (*PendingTx, error) {
+	return m.TryAggregateCalls3AsyncContext(context.Background(), calls, client)
+}
+
+// TryAggregateCalls3AsyncContext is like TryAggregateCalls3Async but broadcasts with ctx.
+func (m *MultiCall) TryAggregateCalls3AsyncContext(
+	ctx context.Context, calls []CallWithFailure, client Backend,
+) (*PendingTx, error) {
+	_, blockNumber, overrides := m.resolve(nil, nil, nil)
+	pending, result := m.sendAggregate(
+		ctx, CallsWithFailure(calls), false, client, blockNumber, overrides,
+		"tryAggregateCalls((address,bytes,uint256,bool)[])", []string{"(bool,bytes)[]"}, false,
+	)
+	return pending, result.Error
+}
+
+// sendAggregate broadcasts the transaction of the aggregate write funcSignature
+// on the Omnes contract, or the aggregate3Value or delegated transaction it is
+// routed to, and returns it pending, or the failed Result when it could not be
+// sent. The transaction is waited for before returning when wait is set.
+// blockNumber and overrides only apply to the preview of delegated calls.
+func (m *MultiCall) sendAggregate(
+	ctx context.Context, calls CallsInterface, requireSuccess bool, client Backend, blockNumber *big.Int, overrides StateOverride,
+	funcSignature string, txReturnTypes []string, wait bool,
+) (*PendingTx, Result) {
+	if m.Signer == nil {
+		return nil, Result{Success: false, Error: ErrNoSigner}
+	}
+
+	withFailure := asCallsWithFailure(calls, requireSuccess)
+
+	if m.Delegation != nil {
+		return m.sendDelegatedCalls(ctx, withFailure, client, blockNumber, overrides, wait)
+	}
+
+	if via, err := m.viaMultiCall3(OperationWrite, nil); err != nil {
+		return nil, Result{Success: false, Error: err}
+	} else if via {
+		return sendWrite(
+			ctx, withFailure, false, client, *m.Signer, m.Fees, m.Replacement, m.Nonces, m.multicall3.ContractAddress,
+			"aggregate3Value((address,bool,uint256,bytes)[])", []string{"(bool,bytes)[]"}, true, true, wait,
+		)
+	}
+
+	if m.ContractAddress == nil {
+		return nil, Result{Success: false, Error: ErrNoMulticallContract}
+	}
+
+	return sendWrite(
+		ctx, calls, requireSuccess, client, *m.Signer, m.Fees, m.Replacement, m.Nonces, m.ContractAddress,
+		funcSignature, txReturnTypes, true, false, wait,
+	)
+}
+
+// asCallsWithFailure returns calls as CallsWithFailure, requiring the success
+// of plain calls when requireSuccess is set. CallsWithFailure carry their own.
+func asCallsWithFailure(calls CallsInterface, requireSuccess bool) CallsWithFailure {
+	if withFailure, ok := calls.(CallsWithFailure); ok {
+		return withFailure
+	}
+	return withRequireSuccess(calls.(Calls), requireSuccess)
+}
+
+// aggregateWrite is like sendAggregate but waits for the transaction and
+// returns its Result.
+func (m *MultiCall) aggregateWrite(
+	ctx context.Context, calls CallsInterface, requireSuccess bool, client Backend, blockNumber *big.Int, overrides StateOverride,
+	funcSignature string, txReturnTypes []string,
+) Result {
+	pending, result := m.sendAggregate(ctx, calls, requireSuccess, client, blockNumber, overrides, funcSignature, txReturnTypes, true)
+	if pending == nil {
+		return result
+	}
+
+	return pending.result
+}
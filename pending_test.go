@@ -0,0 +1,226 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/omnes-tech/abi"
+)
+
+func TestAggregateCallsAsync(t *testing.T) {
+	signer, _ := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	returned, _ := abi.Encode([]string{"uint256"}, big.NewInt(42))
+	preflight, _ := abi.Encode([]string{"bytes[]"}, []any{returned})
+
+	var mined atomic.Bool
+	backend := &fakeBackend{
+		code:        map[common.Address][]byte{OMNES_MULTICALL_ADDRESS: {0x60, 0x80}},
+		blockNumber: 30,
+		gasPrice:    big.NewInt(1),
+		gas:         60000,
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			*result.(*hexutil.Bytes) = preflight
+			return nil
+		},
+		mined: func(tx *types.Transaction) bool { return mined.Load() },
+	}
+	m, err := NewMultiCall(backend, &signer, WithDeployedMode())
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCalls([]common.Address{target}, []string{"a()"}, nil, nil, [][]string{{"uint256"}}, nil)
+
+	pending, err := m.AggregateCallsAsync(calls, backend)
+	if err != nil {
+		t.Fatalf("AggregateCallsAsync: %v", err)
+	}
+	if len(backend.sent) != 1 || pending.Hash != backend.sent[0].Hash() {
+		t.Fatalf("Hash = %s, want the broadcast transaction", pending.Hash)
+	}
+
+	select {
+	case <-pending.Done():
+		t.Fatalf("settled before the transaction was mined")
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := pending.Wait(ctx); result.Error != context.Canceled {
+		t.Fatalf("Wait with a canceled context = %v, want context.Canceled", result.Error)
+	}
+
+	if _, settled := pending.Status(); settled {
+		t.Fatalf("Status settled before the transaction was mined")
+	}
+	mined.Store(true)
+
+	<-pending.Done()
+	result, settled := pending.Status()
+	if !settled || result.Error != nil || !result.Success {
+		t.Fatalf("Status = %+v, %v", result, settled)
+	}
+	if result.TxOrCall.BlockNumber.Uint64() != 30 {
+		t.Fatalf("BlockNumber = %v, want 30", result.TxOrCall.BlockNumber)
+	}
+	callResults := result.CallResults()
+	if len(callResults) != 1 || callResults[0].Decoded[0].(*big.Int).Int64() != 42 {
+		t.Fatalf("CallResults = %v, want 42 decoded", callResults)
+	}
+
+	if waited := pending.Wait(context.Background()); waited.TxOrCall.BlockNumber == nil || waited.Error != nil {
+		t.Fatalf("Wait = %+v, want the settled Result", waited)
+	}
+}
+
+func TestAggregateCalls_ReplacementHashes(t *testing.T) {
+	signer, _ := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	returned, _ := abi.Encode([]string{"uint256"}, big.NewInt(42))
+	preflight, _ := abi.Encode([]string{"bytes[]"}, []any{returned})
+
+	backend := &fakeBackend{
+		code:        map[common.Address][]byte{OMNES_MULTICALL_ADDRESS: {0x60, 0x80}},
+		blockNumber: 30,
+		gasPrice:    big.NewInt(1),
+		gas:         60000,
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			*result.(*hexutil.Bytes) = preflight
+			return nil
+		},
+	}
+	// only the first replacement is mined
+	backend.mined = func(tx *types.Transaction) bool { return len(backend.sent) > 1 && tx.Hash() == backend.sent[1].Hash() }
+	m, err := NewMultiCall(backend, &signer, WithDeployedMode(), WithReplacementPolicy(ReplacementPolicy{Interval: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCalls([]common.Address{target}, []string{"a()"}, nil, nil, [][]string{{"uint256"}}, nil)
+
+	t.Run("sync", func(t *testing.T) {
+		backend.sent = nil
+		result := m.AggregateCalls(calls, backend, nil, nil, false, nil)
+		if result.Error != nil || !result.Success {
+			t.Fatalf("AggregateCalls = %+v", result)
+		}
+		if want := []common.Hash{backend.sent[0].Hash(), backend.sent[1].Hash()}; !slices.Equal(result.TxOrCall.Hashes, want) {
+			t.Fatalf("Hashes = %v, want %v", result.TxOrCall.Hashes, want)
+		}
+	})
+
+	t.Run("async", func(t *testing.T) {
+		backend.sent = nil
+		pending, err := m.AggregateCallsAsync(calls, backend)
+		if err != nil {
+			t.Fatalf("AggregateCallsAsync: %v", err)
+		}
+		result := pending.Wait(context.Background())
+		if result.Error != nil || !result.Success {
+			t.Fatalf("Wait = %+v", result)
+		}
+		want := []common.Hash{pending.Hash, backend.sent[1].Hash()}
+		if !slices.Equal(pending.Hashes(), want) || !slices.Equal(result.TxOrCall.Hashes, want) {
+			t.Fatalf("Hashes = %v and %v, want %v", pending.Hashes(), result.TxOrCall.Hashes, want)
+		}
+	})
+}
+
+func TestAggregateCallsAsync_NoSigner(t *testing.T) {
+	m, err := NewMultiCall(&fakeBackend{}, nil, WithDeployedMode())
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+	if _, err := m.TryAggregateCallsAsync(nil, true, &fakeBackend{}); err != ErrNoSigner {
+		t.Fatalf("err = %v, want ErrNoSigner", err)
+	}
+}
+
+func TestAsyncWriteRoutes(t *testing.T) {
+	signer, _ := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	returned, _ := abi.Encode([]string{"uint256"}, big.NewInt(42))
+
+	t.Run("multicall3", func(t *testing.T) {
+		output, _ := abi.Encode([]string{"(bool,bytes)[]"}, []any{[]any{true, returned}})
+		backend, _ := multicall3OnlyBackend(t, output)
+		backend.gasPrice, backend.gas = big.NewInt(1), 60000
+		backend.mined = func(tx *types.Transaction) bool { return true }
+		m, err := NewMultiCall(backend, &signer, WithDetector(NewDetector()))
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		calls := NewCalls([]common.Address{target}, []string{"a()"}, nil, nil, [][]string{{"uint256"}}, nil)
+		pending, err := m.TryAggregateCallsAsync(calls, true, backend)
+		if err != nil {
+			t.Fatalf("TryAggregateCallsAsync: %v", err)
+		}
+		result := pending.Wait(context.Background())
+		if result.Error != nil || !result.Success {
+			t.Fatalf("Wait = %+v", result)
+		}
+
+		tx := backend.sent[0]
+		if *tx.To() != MULTICALL3_ADDRESS || !bytes.HasPrefix(tx.Data(), abi.EncodeSignature("aggregate3Value((address,bool,uint256,bytes)[])")) {
+			t.Fatalf("tx to %s with data %x, want aggregate3Value on Multicall3", tx.To(), tx.Data())
+		}
+		if callResults := result.CallResults(); len(callResults) != 1 || callResults[0].Decoded[0].(*big.Int).Int64() != 42 {
+			t.Fatalf("CallResults = %v, want 42 decoded", callResults)
+		}
+	})
+
+	t.Run("delegation", func(t *testing.T) {
+		eoa := *signer.GetAddress()
+		implementation := common.HexToAddress("0x0000000000000000000000000000000000000bac")
+		simulation := encodeRevert(t, "MultiCall__Simulation((bool,bytes,uint256)[])", []any{
+			[]any{true, returned, big.NewInt(21000)},
+		})
+		backend := &fakeBackend{
+			blockNumber: 30,
+			gasPrice:    big.NewInt(1),
+			gas:         90000,
+			code:        map[common.Address][]byte{implementation: {0x60, 0x80}},
+			callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				if len(args[0].(CallArgs).Data) == 0 {
+					return &revertErr{simulation}
+				}
+				*result.(*hexutil.Bytes) = nil
+				return nil
+			},
+			mined: func(tx *types.Transaction) bool { return true },
+		}
+		m, err := NewMultiCall(backend, &signer, WithDeploylessMode(), WithDelegation(Delegation{Implementation: implementation}))
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		calls := []CallWithFailure{{Call: NewCall(target, "a()", nil, nil, []string{"uint256"}, nil), RequireSuccess: true}}
+		pending, err := m.TryAggregateCalls3Async(calls, backend)
+		if err != nil {
+			t.Fatalf("TryAggregateCalls3Async: %v", err)
+		}
+		result := pending.Wait(context.Background())
+		if result.Error != nil || !result.Success {
+			t.Fatalf("Wait = %+v", result)
+		}
+
+		wantData, _ := ExecuteBatchCallData(CallsWithFailure(calls).toCalls())
+		if tx := backend.sent[0]; tx.Type() != types.SetCodeTxType || *tx.To() != eoa || !bytes.Equal(tx.Data(), wantData) {
+			t.Fatalf("tx = type %d to %s, want the delegated batch", tx.Type(), tx.To())
+		}
+		if callResults := result.CallResults(); len(callResults) != 1 || callResults[0].Decoded[0].(*big.Int).Int64() != 42 {
+			t.Fatalf("CallResults = %v, want 42 decoded", callResults)
+		}
+	})
+}
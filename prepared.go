@@ -0,0 +1,221 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/omnes-tech/abi"
+)
+
+// PreparedTx is a signed aggregate transaction that was not broadcast, to be
+// submitted later, possibly from another machine, with SendPreparedTransaction.
+// Its nonce stays allocated by the NonceManager of the MultiCall that prepared
+// it until Discard is called.
+type PreparedTx struct {
+	// Raw is the binary encoding of the signed transaction, RLP for legacy
+	// transactions, as eth_sendRawTransaction takes it.
+	Raw []byte
+	// Result holds the call results of the pre-flight call, and the transaction
+	// in TxOrCall.
+	Result Result
+
+	nonces  *NonceManager
+	from    common.Address
+	nonce   uint64
+	discard sync.Once
+}
+
+// Discard gives back the nonce of a prepared transaction that will never be
+// broadcast, so the next transaction fills its gap instead of waiting behind
+// it. It must not be called once the transaction may have been sent, and only
+// the first call has an effect.
+func (p *PreparedTx) Discard() {
+	p.discard.Do(func() {
+		p.nonces.Release(p.from, p.nonce)
+	})
+}
+
+// writeReturnTypes are the return types of the Omnes write functions that
+// transactions can be prepared for.
+var writeReturnTypes = map[string][]string{
+	"aggregateCalls((address,bytes,uint256)[])":         {"bytes[]"},
+	"tryAggregateCalls((address,bytes,uint256)[],bool)": {"(bool,bytes)[]"},
+	"tryAggregateCalls((address,bytes,uint256,bool)[])": {"(bool,bytes)[]"},
+}
+
+// PrepareAggregateCalls builds, estimates and signs the transaction AggregateCalls
+// would send, without broadcasting it. Its nonce stays allocated by m.Nonces,
+// see PreparedTx.Discard.
+func (m *MultiCall) PrepareAggregateCalls(calls []Call, client Backend) (*PreparedTx, error) {
+	return m.PrepareAggregateCallsContext(context.Background(), calls, client)
+}
+
+// PrepareAggregateCallsContext is like PrepareAggregateCalls but issues every RPC with ctx.
+func (m *MultiCall) PrepareAggregateCallsContext(ctx context.Context, calls []Call, client Backend) (*PreparedTx, error) {
+	return m.prepare(ctx, Calls(calls), false, client, "aggregateCalls((address,bytes,uint256)[])")
+}
+
+// PrepareTryAggregateCalls builds, estimates and signs the transaction
+// TryAggregateCalls would send, without broadcasting it.
+func (m *MultiCall) PrepareTryAggregateCalls(calls []Call, requireSuccess bool, client Backend) (*PreparedTx, error) {
+	return m.PrepareTryAggregateCallsContext(context.Background(), calls, requireSuccess, client)
+}
+
+// PrepareTryAggregateCallsContext is like PrepareTryAggregateCalls but issues every RPC with ctx.
+func (m *MultiCall) PrepareTryAggregateCallsContext(
+	ctx context.Context, calls []Call, requireSuccess bool, client Backend,
+) (*PreparedTx, error) {
+	return m.prepare(ctx, Calls(calls), requireSuccess, client, "tryAggregateCalls((address,bytes,uint256)[],bool)")
+}
+
+// PrepareTryAggregateCalls3 builds, estimates and signs the transaction
+// TryAggregateCalls3 would send, without broadcasting it.
+func (m *MultiCall) PrepareTryAggregateCalls3(calls []CallWithFailure, client Backend) (*PreparedTx, error) {
+	return m.PrepareTryAggregateCalls3Context(context.Background(), calls, client)
+}
+
+// PrepareTryAggregateCalls3Context is like PrepareTryAggregateCalls3 but issues every RPC with ctx.
+func (m *MultiCall) PrepareTryAggregateCalls3Context(
+	ctx context.Context, calls []CallWithFailure, client Backend,
+) (*PreparedTx, error) {
+	return m.prepare(ctx, CallsWithFailure(calls), false, client, "tryAggregateCalls((address,bytes,uint256,bool)[])")
+}
+
+func (m *MultiCall) prepare(
+	ctx context.Context, calls CallsInterface, requireSuccess bool, client Backend, funcSignature string,
+) (*PreparedTx, error) {
+	if err := m.checkOmnesWrite(); err != nil {
+		return nil, err
+	}
+	signer := *m.Signer
+
+	chainId, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	signedTx, encodedCallResult, result := buildWrite(
+		ctx, calls, requireSuccess, client, signer, chainId, m.Fees, m.Nonces, m.ContractAddress, funcSignature, true, false,
+	)
+	if signedTx == nil {
+		return nil, result.Error
+	}
+	from := *signer.GetAddress()
+	txOrCall := FromTxToTxOrCall(signedTx, from, nil, nil)
+
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		m.Nonces.Release(from, signedTx.Nonce())
+		return nil, fmt.Errorf("error encoding transaction: %w", err)
+	}
+
+	callResults, err := decodeWriteResults(writeReturnTypes[funcSignature], encodedCallResult, calls)
+	if err != nil {
+		m.Nonces.Release(from, signedTx.Nonce())
+		return nil, err
+	}
+
+	return &PreparedTx{
+		Raw:    raw,
+		Result: Result{Success: true, Result: callResults, TxOrCall: txOrCall},
+		nonces: m.Nonces,
+		from:   from,
+		nonce:  signedTx.Nonce(),
+	}, nil
+}
+
+// SendPreparedTransaction broadcasts raw, a signed transaction of a Prepare
+// method, once a fresh pre-flight call succeeds, and waits for it to be mined.
+// The call results are decoded for calls, the calls the transaction was
+// prepared for.
+func SendPreparedTransaction(raw []byte, calls CallsInterface, client Backend) Result {
+	return SendPreparedTransactionContext(context.Background(), raw, calls, client)
+}
+
+// SendPreparedTransactionContext is like SendPreparedTransaction but issues every RPC with ctx.
+func SendPreparedTransactionContext(ctx context.Context, raw []byte, calls CallsInterface, client Backend) Result {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return Result{Success: false, Error: fmt.Errorf("error decoding transaction: %w", err)}
+	}
+
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return Result{Success: false, Error: fmt.Errorf("error recovering transaction sender: %w", err)}
+	}
+	if tx.To() == nil {
+		return Result{Success: false, Error: fmt.Errorf("transaction %s creates a contract", tx.Hash())}
+	}
+
+	var txReturnTypes []string
+	for funcSignature, returnTypes := range writeReturnTypes {
+		if bytes.HasPrefix(tx.Data(), abi.EncodeSignature(funcSignature)) {
+			txReturnTypes = returnTypes
+		}
+	}
+	if txReturnTypes == nil {
+		return Result{
+			Success:  false,
+			Error:    fmt.Errorf("transaction %s does not call a multicall write function", tx.Hash()),
+			TxOrCall: FromTxToTxOrCall(tx, from, nil, nil),
+		}
+	}
+
+	encodedCallResult, _, err := readContract(ctx, client, &from, tx.To(), tx.Value(), tx.Data(), nil, nil)
+	if err != nil {
+		return Result{
+			Success:  false,
+			Error:    fmt.Errorf("error calling contract: %w", decodeMultiCallError(err, calls)),
+			TxOrCall: FromTxToTxOrCall(tx, from, nil, nil),
+		}
+	}
+
+	receipt, tx, hashes, err := sendSignedTransaction(ctx, client, tx, ReplacementPolicy{}, nil, nil)
+	if err != nil {
+		txOrCall := FromTxToTxOrCall(tx, from, nil, nil)
+		txOrCall.Hashes = hashes
+		return Result{
+			Success:  false,
+			Error:    fmt.Errorf("error sending signed transaction: %w", err),
+			TxOrCall: txOrCall,
+		}
+	}
+	txOrCall := FromTxToTxOrCall(tx, from, receipt.BlockNumber, nil)
+	txOrCall.Hashes = hashes
+
+	callResults, err := decodeWriteResults(txReturnTypes, encodedCallResult, calls)
+	if err != nil {
+		return Result{Success: false, Error: err, TxOrCall: txOrCall}
+	}
+
+	return Result{
+		Success:  receipt.Status == types.ReceiptStatusSuccessful,
+		Result:   callResults,
+		TxOrCall: txOrCall,
+	}
+}
+
+// checkOmnesWrite checks writes can be prepared for the Omnes contract, the
+// only one whose transactions SendPreparedTransaction decodes.
+func (m *MultiCall) checkOmnesWrite() error {
+	if m.Signer == nil {
+		return ErrNoSigner
+	}
+	if m.Delegation != nil {
+		return fmt.Errorf("delegated calls cannot be prepared")
+	}
+	if via, err := m.viaMultiCall3(OperationWrite, nil); err != nil {
+		return err
+	} else if via {
+		return fmt.Errorf("writes routed to %s cannot be prepared", m.multicall3.ContractAddress.Hex())
+	}
+	if m.ContractAddress == nil {
+		return ErrNoMulticallContract
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package multicall
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/omnes-tech/abi"
+)
+
+func TestPrepareAndSendTransaction(t *testing.T) {
+	signer, _ := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	returned, _ := abi.Encode([]string{"uint256"}, big.NewInt(42))
+	preflight, _ := abi.Encode([]string{"(bool,bytes)[]"}, []any{[]any{true, returned}})
+
+	var preflights int
+	backend := &fakeBackend{
+		code:        map[common.Address][]byte{OMNES_MULTICALL_ADDRESS: {0x60, 0x80}},
+		blockNumber: 8,
+		gasPrice:    big.NewInt(1),
+		gas:         60000,
+		nonce:       4,
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			preflights++
+			*result.(*hexutil.Bytes) = preflight
+			return nil
+		},
+	}
+	m, err := NewMultiCall(backend, &signer, WithDeployedMode())
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCalls([]common.Address{target}, []string{"a()"}, nil, nil, [][]string{{"uint256"}}, nil)
+
+	prepared, err := m.PrepareTryAggregateCalls(calls, false, backend)
+	if err != nil {
+		t.Fatalf("PrepareTryAggregateCalls: %v", err)
+	}
+	if len(backend.sent) != 0 {
+		t.Fatalf("a prepared transaction was broadcast")
+	}
+	if callResults := prepared.Result.CallResults(); len(callResults) != 1 || callResults[0].Decoded[0].(*big.Int).Int64() != 42 {
+		t.Fatalf("CallResults = %v, want the pre-flight results", callResults)
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(prepared.Raw); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), tx)
+	if err != nil || sender != *signer.GetAddress() || tx.Nonce() != 4 || *tx.To() != OMNES_MULTICALL_ADDRESS {
+		t.Fatalf("tx from %s (%v), nonce %d, to %s", sender, err, tx.Nonce(), tx.To())
+	}
+
+	result := SendPreparedTransaction(prepared.Raw, calls, backend)
+	if result.Error != nil || !result.Success {
+		t.Fatalf("SendPreparedTransaction = %+v", result)
+	}
+	if len(backend.sent) != 1 || backend.sent[0].Hash() != tx.Hash() || preflights != 2 {
+		t.Fatalf("sent %d transactions after %d pre-flight calls", len(backend.sent), preflights)
+	}
+	if callResults := result.CallResults(); len(callResults) != 1 || result.TxOrCall.BlockNumber.Uint64() != 8 {
+		t.Fatalf("Result = %+v", result)
+	}
+
+	t.Run("discard releases the nonce", func(t *testing.T) {
+		nonces := NewNonceManager()
+		managed, err := NewMultiCall(backend, &signer, WithDeployedMode(), WithNonceManager(nonces))
+		if err != nil {
+			t.Fatalf("NewMultiCall: %v", err)
+		}
+
+		discarded, err := managed.PrepareTryAggregateCalls(calls, false, backend)
+		if err != nil {
+			t.Fatalf("PrepareTryAggregateCalls: %v", err)
+		}
+		if _, err := managed.PrepareTryAggregateCalls(calls, false, backend); err != nil {
+			t.Fatalf("PrepareTryAggregateCalls: %v", err)
+		}
+
+		discarded.Discard()
+		if got, _ := nonces.Next(context.Background(), backend, *signer.GetAddress()); got != 4 {
+			t.Fatalf("Next = %d, want the discarded 4", got)
+		}
+		// the nonce is now used by another transaction
+		discarded.Discard()
+		if got, _ := nonces.Next(context.Background(), backend, *signer.GetAddress()); got != 6 {
+			t.Fatalf("Next = %d, want 6 after discarding twice", got)
+		}
+	})
+
+	t.Run("rejects other transactions", func(t *testing.T) {
+		other, _ := signer.SignTx(types.NewTransaction(0, target, nil, 21000, big.NewInt(1), []byte{0x01}), big.NewInt(1))
+		raw, _ := other.MarshalBinary()
+
+		if result := SendPreparedTransaction(raw, calls, backend); result.Error == nil {
+			t.Fatalf("Error = nil, want a transaction not calling a write function to be rejected")
+		}
+	})
+}
+
+func TestPrepare_Delegation(t *testing.T) {
+	signer, _ := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	backend := &fakeBackend{gasPrice: big.NewInt(1)}
+	m, err := NewMultiCall(backend, &signer, WithDeployedMode(), WithDelegation(Delegation{Implementation: common.HexToAddress("0xbac")}))
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCalls([]common.Address{target}, []string{"a()"}, nil, nil, nil, nil)
+	prepares := map[string]func() (*PreparedTx, error){
+		"aggregate":     func() (*PreparedTx, error) { return m.PrepareAggregateCalls(calls, backend) },
+		"try aggregate": func() (*PreparedTx, error) { return m.PrepareTryAggregateCalls(calls, true, backend) },
+		"try aggregate 3": func() (*PreparedTx, error) {
+			return m.PrepareTryAggregateCalls3([]CallWithFailure{{Call: calls[0]}}, backend)
+		},
+	}
+	for name, prepare := range prepares {
+		t.Run(name, func(t *testing.T) {
+			if _, err := prepare(); err == nil || !strings.Contains(err.Error(), "delegated calls cannot be prepared") {
+				t.Fatalf("err = %v, want delegated calls to be refused", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// MIN_REPLACEMENT_BUMP is the fee increase, in percent, nodes require to accept
+// a transaction replacing a pending one with the same nonce.
+const MIN_REPLACEMENT_BUMP = 10
+
+// TRANSACTION_POLL_INTERVAL is how often receipts of sent transactions are polled.
+const TRANSACTION_POLL_INTERVAL = time.Second
+
+// ReplacementPolicy replaces a sent transaction that is not mined within
+// Interval by the same transaction, with the same nonce, and fees bumped by
+// BumpPercent. Replacements continue every Interval until one of the broadcast
+// transactions is mined or the bumped fees would exceed MaxFeeCap. The zero
+// value never replaces transactions.
+type ReplacementPolicy struct {
+	Interval time.Duration
+	// BumpPercent is raised to MIN_REPLACEMENT_BUMP when lower.
+	BumpPercent uint64
+	// MaxFeeCap caps the gas price or fee cap of replacements, unbounded when nil.
+	MaxFeeCap *big.Int
+}
+
+// bumpFee raises fee by percent, rounding up so the bump is never lost to integer division.
+func bumpFee(fee *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// bump returns tx with its fees bumped, or false when the policy's fee ceiling
+// leaves no room for a replacement nodes would accept.
+func (p ReplacementPolicy) bump(tx *types.Transaction) (*types.Transaction, bool) {
+	percent := p.BumpPercent
+	if percent < MIN_REPLACEMENT_BUMP {
+		percent = MIN_REPLACEMENT_BUMP
+	}
+
+	// the ceiling may cut the requested bump down to the minimum one
+	feeCap := bumpFee(tx.GasFeeCap(), percent)
+	if p.MaxFeeCap != nil && feeCap.Cmp(p.MaxFeeCap) > 0 {
+		feeCap = new(big.Int).Set(p.MaxFeeCap)
+	}
+	if feeCap.Cmp(bumpFee(tx.GasFeeCap(), MIN_REPLACEMENT_BUMP)) < 0 {
+		return nil, false
+	}
+	tipCap := bumpFee(tx.GasTipCap(), percent)
+	if tipCap.Cmp(feeCap) > 0 {
+		tipCap = feeCap
+	}
+	if tipCap.Cmp(bumpFee(tx.GasTipCap(), MIN_REPLACEMENT_BUMP)) < 0 {
+		return nil, false
+	}
+
+	switch tx.Type() {
+	case types.LegacyTxType:
+		return types.NewTx(&types.LegacyTx{
+			Nonce: tx.Nonce(), GasPrice: feeCap, Gas: tx.Gas(), To: tx.To(), Value: tx.Value(), Data: tx.Data(),
+		}), true
+	case types.DynamicFeeTxType:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID: tx.ChainId(), Nonce: tx.Nonce(), GasTipCap: tipCap, GasFeeCap: feeCap, Gas: tx.Gas(),
+			To: tx.To(), Value: tx.Value(), Data: tx.Data(), AccessList: tx.AccessList(),
+		}), true
+	case types.SetCodeTxType:
+		return types.NewTx(&types.SetCodeTx{
+			ChainID: uint256.MustFromBig(tx.ChainId()), Nonce: tx.Nonce(), GasTipCap: uint256.MustFromBig(tipCap),
+			GasFeeCap: uint256.MustFromBig(feeCap), Gas: tx.Gas(), To: *tx.To(), Value: uint256.MustFromBig(tx.Value()),
+			Data: tx.Data(), AccessList: tx.AccessList(), AuthList: tx.SetCodeAuthorizations(),
+		}), true
+	}
+
+	return nil, false
+}
+
+// waitReplacing waits for tx, already sent, or one of its replacements to be
+// mined, and returns the receipt and transaction of the one that was, or the
+// last transaction sent on failure, with the hashes of every transaction sent.
+// sign signs the replacements, and replaced, when not nil, is called with each
+// one once broadcast.
+func waitReplacing(
+	ctx context.Context, client Backend, tx *types.Transaction, policy ReplacementPolicy,
+	sign func(tx *types.Transaction) (*types.Transaction, error), replaced func(tx *types.Transaction),
+) (*types.Receipt, *types.Transaction, []common.Hash, error) {
+	sent := []*types.Transaction{tx}
+	hashes := func() []common.Hash {
+		hashes := make([]common.Hash, len(sent))
+		for i, candidate := range sent {
+			hashes[i] = candidate.Hash()
+		}
+		return hashes
+	}
+	replaceAt := time.Now().Add(policy.Interval)
+
+	pollInterval := TRANSACTION_POLL_INTERVAL
+	if policy.Interval > 0 && policy.Interval < pollInterval {
+		pollInterval = policy.Interval
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, candidate := range sent {
+			if receipt, err := client.TransactionReceipt(ctx, candidate.Hash()); err == nil && receipt != nil {
+				return receipt, candidate, hashes(), nil
+			}
+		}
+
+		if policy.Interval > 0 && !time.Now().Before(replaceAt) {
+			replaceAt = time.Now().Add(policy.Interval)
+
+			if bumped, ok := policy.bump(sent[len(sent)-1]); ok {
+				signed, err := sign(bumped)
+				if err != nil {
+					return nil, sent[len(sent)-1], hashes(), fmt.Errorf("error signing replacement transaction: %w", err)
+				}
+				// a rejected replacement, e.g. because a previous transaction was
+				// just mined, leaves the broadcast ones to be waited for
+				if err := client.SendTransaction(ctx, signed); err == nil {
+					sent = append(sent, signed)
+					if replaced != nil {
+						replaced(signed)
+					}
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			hashes := hashes()
+			return nil, sent[len(sent)-1], hashes, fmt.Errorf("error while waiting for receipt (txHashes=%v): %w", hashes, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
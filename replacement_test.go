@@ -0,0 +1,108 @@
+package multicall
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestReplacementPolicyBump(t *testing.T) {
+	to := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	dynamic := types.NewTx(&types.DynamicFeeTx{
+		ChainID: big.NewInt(1), Nonce: 3, GasTipCap: big.NewInt(10), GasFeeCap: big.NewInt(100), Gas: 21000, To: &to,
+	})
+
+	tests := []struct {
+		name       string
+		policy     ReplacementPolicy
+		tx         *types.Transaction
+		wantOK     bool
+		wantFeeCap int64
+		wantTipCap int64
+	}{
+		{"minimum bump", ReplacementPolicy{BumpPercent: 1}, dynamic, true, 110, 11},
+		{"requested bump", ReplacementPolicy{BumpPercent: 50}, dynamic, true, 150, 15},
+		{"capped bump", ReplacementPolicy{BumpPercent: 50, MaxFeeCap: big.NewInt(120)}, dynamic, true, 120, 15},
+		{"ceiling reached", ReplacementPolicy{MaxFeeCap: big.NewInt(105)}, dynamic, false, 0, 0},
+		{
+			"legacy gas price", ReplacementPolicy{BumpPercent: 20},
+			types.NewTransaction(3, to, nil, 21000, big.NewInt(7), nil), true, 9, 9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bumped, ok := tt.policy.bump(tt.tx)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if bumped.Type() != tt.tx.Type() || bumped.Nonce() != tt.tx.Nonce() || *bumped.To() != to {
+				t.Fatalf("bumped = type %d nonce %d to %s", bumped.Type(), bumped.Nonce(), bumped.To())
+			}
+			if bumped.GasFeeCap().Int64() != tt.wantFeeCap || bumped.GasTipCap().Int64() != tt.wantTipCap {
+				t.Fatalf("fees = %s, %s, want %d, %d", bumped.GasFeeCap(), bumped.GasTipCap(), tt.wantFeeCap, tt.wantTipCap)
+			}
+		})
+	}
+}
+
+func TestSendSignedTransaction_Replacement(t *testing.T) {
+	signer, _ := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	to := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	chainID := big.NewInt(1)
+
+	backend := &fakeBackend{
+		blockNumber: 12,
+		gasPrice:    big.NewInt(1),
+		// only the second replacement is mined
+		mined: func(tx *types.Transaction) bool { return tx.GasFeeCap().Int64() == 121 },
+	}
+
+	tx, err := signer.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(10), GasFeeCap: big.NewInt(100), Gas: 21000, To: &to,
+	}), chainID)
+	if err != nil {
+		t.Fatalf("SignTx: %v", err)
+	}
+
+	policy := ReplacementPolicy{Interval: time.Millisecond}
+	receipt, mined, hashes, err := sendSignedTransaction(context.Background(), backend, tx, policy, signWith(signer, chainID), nil)
+	if err != nil {
+		t.Fatalf("sendSignedTransaction: %v", err)
+	}
+	if len(backend.sent) != 3 {
+		t.Fatalf("sent %d transactions, want the original and 2 replacements", len(backend.sent))
+	}
+	if mined.Hash() != backend.sent[2].Hash() || receipt.TxHash != mined.Hash() || mined.Nonce() != 3 {
+		t.Fatalf("mined = %s, want the last replacement %s", mined.Hash(), backend.sent[2].Hash())
+	}
+	if want := []common.Hash{tx.Hash(), backend.sent[1].Hash(), mined.Hash()}; !slices.Equal(hashes, want) {
+		t.Fatalf("hashes = %v, want %v", hashes, want)
+	}
+
+	t.Run("waits for the broadcast transactions once the ceiling is reached", func(t *testing.T) {
+		backend.sent = nil
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		policy := ReplacementPolicy{Interval: time.Millisecond, MaxFeeCap: big.NewInt(115)}
+		_, _, hashes, err := sendSignedTransaction(ctx, backend, tx, policy, signWith(signer, chainID), nil)
+		if err == nil {
+			t.Fatalf("err = nil, want a timeout")
+		}
+		if len(backend.sent) != 2 {
+			t.Fatalf("sent %d transactions, want the original and 1 replacement", len(backend.sent))
+		}
+		if want := []common.Hash{tx.Hash(), backend.sent[1].Hash()}; !slices.Equal(hashes, want) {
+			t.Fatalf("hashes = %v, want %v", hashes, want)
+		}
+	})
+}
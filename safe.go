@@ -0,0 +1,216 @@
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/omnes-tech/abi"
+)
+
+// SAFE_MULTISEND_ADDRESS is the Safe v1.4.1 MultiSend deployment, delegate called by Safe wallets
+// to execute a batch.
+var SAFE_MULTISEND_ADDRESS = common.HexToAddress("0x38869bf66a61cF6bDB996A6aE40D5853Fd43B526")
+
+type SafeOperation uint8
+
+const (
+	SafeCall SafeOperation = iota
+	SafeDelegateCall
+)
+
+var (
+	safeDomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+	safeTxTypeHash     = crypto.Keccak256Hash([]byte(
+		"SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas," +
+			"uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)",
+	))
+)
+
+// storage slots of SafeStorage
+var (
+	safeOwnersSlot         = big.NewInt(2)
+	safeThresholdSlot      = common.BigToHash(big.NewInt(4))
+	safeNonceSlot          = common.BigToHash(big.NewInt(5))
+	safeApprovedHashesSlot = big.NewInt(8)
+)
+
+// safeSimulationOwner is made an owner of the Safe, having approved the
+// transaction, when simulating it.
+var safeSimulationOwner = common.HexToAddress("0x0000000000000000000000000000000000005afe")
+
+// SafeTransaction is a transaction executed by a Safe wallet through execTransaction.
+type SafeTransaction struct {
+	Safe           common.Address
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      SafeOperation
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          *big.Int
+}
+
+// MultiSendData packs calls as the transactions argument of MultiSend.multiSend:
+// operation (1 byte), to (20 bytes), value (32 bytes), data length (32 bytes)
+// and data, for every call. Calls are always sent with the call operation.
+func MultiSendData(calls CallsInterface) ([]byte, error) {
+	if withFailure, ok := calls.(CallsWithFailureInterface); ok {
+		for i := 0; i < withFailure.Len(); i++ {
+			if !withFailure.GetRequireSuccess(i) {
+				return nil, fmt.Errorf("call %d: MultiSend cannot let calls fail", i)
+			}
+		}
+	}
+
+	array, _, err := calls.ToArray(true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var packed []byte
+	for _, entry := range array {
+		fields := entry.([]any)
+		to, callData, value := fields[0].(*common.Address), fields[1].([]byte), fields[2].(*big.Int)
+
+		packed = append(packed, byte(SafeCall))
+		packed = append(packed, to.Bytes()...)
+		packed = append(packed, word(value)...)
+		packed = append(packed, word(big.NewInt(int64(len(callData))))...)
+		packed = append(packed, callData...)
+	}
+
+	return packed, nil
+}
+
+// NewSafeMultiSend wraps calls in a Safe transaction delegate calling
+// SAFE_MULTISEND_ADDRESS. The values of the calls are paid from the Safe balance.
+func NewSafeMultiSend(safe common.Address, calls CallsInterface, nonce *big.Int) (*SafeTransaction, error) {
+	transactions, err := MultiSendData(calls)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := abi.EncodeWithSignature("multiSend(bytes)", transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SafeTransaction{
+		Safe:      safe,
+		To:        SAFE_MULTISEND_ADDRESS,
+		Data:      data,
+		Operation: SafeDelegateCall,
+		Nonce:     nonce,
+	}, nil
+}
+
+// SafeNonce returns the nonce the next transaction of safe must use.
+func SafeNonce(client Backend, safe common.Address) (*big.Int, error) {
+	return SafeNonceContext(context.Background(), client, safe)
+}
+
+// SafeNonceContext is like SafeNonce but issues the RPC with ctx.
+func SafeNonceContext(ctx context.Context, client Backend, safe common.Address) (*big.Int, error) {
+	result := getData(ctx, nil, client, &safe, "nonce()", []string{"uint256"}, nil)
+	if result.Error != nil {
+		return nil, fmt.Errorf("error getting safe nonce: %w", result.Error)
+	}
+
+	return result.Result.([]any)[0].(*big.Int), nil
+}
+
+// Hash returns the EIP-712 safeTxHash owners sign, for Safe v1.3.0 and later.
+func (tx *SafeTransaction) Hash(chainID *big.Int) common.Hash {
+	domainSeparator := crypto.Keccak256(
+		safeDomainTypeHash.Bytes(),
+		word(chainID),
+		common.LeftPadBytes(tx.Safe.Bytes(), 32),
+	)
+
+	structHash := crypto.Keccak256(
+		safeTxTypeHash.Bytes(),
+		common.LeftPadBytes(tx.To.Bytes(), 32),
+		word(tx.Value),
+		crypto.Keccak256(tx.Data),
+		word(big.NewInt(int64(tx.Operation))),
+		word(tx.SafeTxGas),
+		word(tx.BaseGas),
+		word(tx.GasPrice),
+		common.LeftPadBytes(tx.GasToken.Bytes(), 32),
+		common.LeftPadBytes(tx.RefundReceiver.Bytes(), 32),
+		word(tx.Nonce),
+	)
+
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator, structHash)
+}
+
+// ExecCall returns the execTransaction call to tx.Safe carrying signatures.
+func (tx *SafeTransaction) ExecCall(signatures []byte) (Call, error) {
+	callData, err := abi.EncodeWithSignature(
+		"execTransaction(address,uint256,bytes,uint8,uint256,uint256,uint256,address,address,bytes)",
+		&tx.To, orZero(tx.Value), tx.Data, big.NewInt(int64(tx.Operation)), orZero(tx.SafeTxGas),
+		orZero(tx.BaseGas), orZero(tx.GasPrice), &tx.GasToken, &tx.RefundReceiver, signatures,
+	)
+	if err != nil {
+		return Call{}, err
+	}
+
+	return NewCall(tx.Safe, "", nil, callData, []string{"bool"}, nil), nil
+}
+
+// simulationOverride lets safeSimulationOwner alone execute tx: the threshold
+// is set to 1, the nonce to tx.Nonce, and safeSimulationOwner is made an owner
+// that approved the transaction hash.
+func (tx *SafeTransaction) simulationOverride(chainID *big.Int) StateOverride {
+	owner := common.LeftPadBytes(safeSimulationOwner.Bytes(), 32)
+	ownerSlot := crypto.Keccak256Hash(owner, word(safeOwnersSlot))
+	approvedSlot := crypto.Keccak256Hash(
+		tx.Hash(chainID).Bytes(),
+		crypto.Keccak256(owner, word(safeApprovedHashesSlot)),
+	)
+
+	return StateOverride{tx.Safe: {StateDiff: map[common.Hash]common.Hash{
+		safeThresholdSlot: common.BigToHash(big.NewInt(1)),
+		safeNonceSlot:     common.BigToHash(orZero(tx.Nonce)),
+		ownerSlot:         common.BigToHash(big.NewInt(1)), // any non zero pointer in the owners list
+		approvedSlot:      common.BigToHash(big.NewInt(1)),
+	}}}
+}
+
+func (m *MultiCall) SimulateSafeTransaction(
+	tx *SafeTransaction, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	return m.SimulateSafeTransactionContext(context.Background(), tx, client, from, blockNumber, overrides)
+}
+
+// SimulateSafeTransactionContext runs tx through SimulateCall without any owner
+// signature: state overrides set the Safe threshold to 1 and approve the
+// transaction by a stand-in owner. overrides are added to them with StateOverride.Add.
+func (m *MultiCall) SimulateSafeTransactionContext(
+	ctx context.Context, tx *SafeTransaction, client Backend, from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) Result {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return Result{Success: false, Error: fmt.Errorf("error getting chain id: %w", err)}
+	}
+
+	// pre-validated signature (v = 1) of safeSimulationOwner
+	signature := append(common.LeftPadBytes(safeSimulationOwner.Bytes(), 32), make([]byte, 32)...)
+	signature = append(signature, 1)
+
+	execCall, err := tx.ExecCall(signature)
+	if err != nil {
+		return Result{Success: false, Error: fmt.Errorf("error encoding execTransaction: %w", err)}
+	}
+
+	simulationOverrides := tx.simulationOverride(chainID)
+	simulationOverrides.Add(overrides)
+
+	return m.SimulateCallContext(ctx, []Call{execCall}, client, from, blockNumber, simulationOverrides)
+}
@@ -0,0 +1,137 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/omnes-tech/abi"
+)
+
+func TestMultiSendData(t *testing.T) {
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	calls := NewCalls(
+		[]common.Address{target, target}, []string{"a()", "b()"}, nil, nil, nil, []*big.Int{big.NewInt(7), nil},
+	)
+
+	packed, err := MultiSendData(calls)
+	if err != nil {
+		t.Fatalf("MultiSendData: %v", err)
+	}
+
+	var want []byte
+	for i, value := range []int64{7, 0} {
+		want = append(want, 0)
+		want = append(want, target.Bytes()...)
+		want = append(want, common.LeftPadBytes(big.NewInt(value).Bytes(), 32)...)
+		want = append(want, common.LeftPadBytes([]byte{4}, 32)...)
+		want = append(want, abi.EncodeSignature(calls[i].FuncSignature)...)
+	}
+	if !bytes.Equal(packed, want) {
+		t.Fatalf("packed = %x, want %x", packed, want)
+	}
+
+	optional := NewCallsWithFailure([]common.Address{target}, []string{"a()"}, nil, nil, nil, nil, []bool{false})
+	if _, err := MultiSendData(optional); err == nil {
+		t.Fatalf("err = nil, want calls allowed to fail to be rejected")
+	}
+}
+
+func TestSafeTransactionHash(t *testing.T) {
+	safe := common.HexToAddress("0x000000000000000000000000000000000000a11c")
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	tx, err := NewSafeMultiSend(safe, NewCalls([]common.Address{target}, []string{"a()"}, nil, nil, nil, nil), big.NewInt(3))
+	if err != nil {
+		t.Fatalf("NewSafeMultiSend: %v", err)
+	}
+
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {{Name: "chainId", Type: "uint256"}, {Name: "verifyingContract", Type: "address"}},
+			"SafeTx": {
+				{Name: "to", Type: "address"}, {Name: "value", Type: "uint256"}, {Name: "data", Type: "bytes"},
+				{Name: "operation", Type: "uint8"}, {Name: "safeTxGas", Type: "uint256"}, {Name: "baseGas", Type: "uint256"},
+				{Name: "gasPrice", Type: "uint256"}, {Name: "gasToken", Type: "address"},
+				{Name: "refundReceiver", Type: "address"}, {Name: "nonce", Type: "uint256"},
+			},
+		},
+		PrimaryType: "SafeTx",
+		Domain:      apitypes.TypedDataDomain{ChainId: math.NewHexOrDecimal256(1), VerifyingContract: safe.Hex()},
+		Message: apitypes.TypedDataMessage{
+			"to": SAFE_MULTISEND_ADDRESS.Hex(), "value": "0", "data": hexutil.Encode(tx.Data), "operation": "1",
+			"safeTxGas": "0", "baseGas": "0", "gasPrice": "0", "gasToken": ZERO_ADDRESS.Hex(),
+			"refundReceiver": ZERO_ADDRESS.Hex(), "nonce": "3",
+		},
+	}
+	want, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		t.Fatalf("TypedDataAndHash: %v", err)
+	}
+
+	if got := tx.Hash(big.NewInt(1)); !bytes.Equal(got.Bytes(), want) {
+		t.Fatalf("Hash = %s, want %x", got, want)
+	}
+}
+
+func TestSimulateSafeTransaction(t *testing.T) {
+	execOutput, _ := abi.Encode([]string{"bool"}, true)
+	simulation := encodeRevert(t, "MultiCall__Simulation((bool,bytes,uint256)[])", []any{
+		[]any{true, execOutput, big.NewInt(60000)},
+	})
+
+	safe := common.HexToAddress("0x000000000000000000000000000000000000a11c")
+	var sent StateOverride
+	backend := &fakeBackend{
+		blockNumber: 1,
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			sent = args[2].(StateOverride)
+			return &revertErr{simulation}
+		},
+	}
+	m, err := NewMultiCall(backend, nil, WithDeploylessMode())
+	if err != nil {
+		t.Fatalf("NewMultiCall: %v", err)
+	}
+
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	tx, _ := NewSafeMultiSend(safe, NewCalls([]common.Address{target}, []string{"a()"}, nil, nil, nil, nil), big.NewInt(3))
+
+	result := m.SimulateSafeTransaction(tx, backend, nil, nil, nil)
+	if result.Error != nil {
+		t.Fatalf("Error = %v", result.Error)
+	}
+	if callResults := result.CallResults(); len(callResults) != 1 || callResults[0].Decoded[0] != true {
+		t.Fatalf("CallResults = %v, want execTransaction to return true", callResults)
+	}
+
+	stateDiff := sent[safe].StateDiff
+	if stateDiff[safeThresholdSlot] != common.BigToHash(big.NewInt(1)) || stateDiff[safeNonceSlot] != common.BigToHash(big.NewInt(3)) {
+		t.Fatalf("StateDiff = %v, want threshold 1 and nonce 3", stateDiff)
+	}
+	if len(stateDiff) != 4 {
+		t.Fatalf("StateDiff = %v, want the owner and approval slots too", stateDiff)
+	}
+}
+
+func TestSafeNonce(t *testing.T) {
+	safe := common.HexToAddress("0x0000000000000000000000000000000000005afe")
+	nonce, _ := abi.Encode([]string{"uint256"}, big.NewInt(12))
+	backend := &fakeBackend{
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if call := args[0].(CallArgs); *call.To != safe || !bytes.Equal(call.Data, abi.EncodeSignature("nonce()")) {
+				t.Fatalf("call to %s with data %x, want nonce() on the safe", call.To, call.Data)
+			}
+			*result.(*hexutil.Bytes) = nonce
+			return nil
+		},
+	}
+
+	if got, err := SafeNonce(backend, safe); err != nil || got.Int64() != 12 {
+		t.Fatalf("SafeNonce = %v, %v; want 12", got, err)
+	}
+}
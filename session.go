@@ -0,0 +1,168 @@
+package multicall
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// CallOpts holds per-call settings for Session methods. Unset fields fall back
+// to the session, then to MultiCall.Defaults.
+type CallOpts struct {
+	Context        context.Context
+	From           *common.Address
+	BlockNumber    *big.Int
+	StateOverrides StateOverride
+	// IsCall runs write methods as an eth_call instead of sending a transaction.
+	IsCall bool
+}
+
+// Session binds a MultiCall to a backend and to a sender, block and state
+// overrides, so they don't need to be repeated on every call.
+// Sessions are immutable: AtBlock, From, WithStateOverride and WithContext
+// return child sessions and leave the receiver untouched.
+type Session struct {
+	multicall *MultiCall
+	backend   Backend
+	opts      CallOpts
+}
+
+// Session creates a session issuing every call through client.
+func (m *MultiCall) Session(client Backend) *Session {
+	return &Session{
+		multicall: m,
+		backend:   client,
+		opts:      CallOpts{Context: context.Background()},
+	}
+}
+
+func (s *Session) MultiCall() *MultiCall {
+	return s.multicall
+}
+
+func (s *Session) Backend() Backend {
+	return s.backend
+}
+
+// AtBlock returns a child session pinned to blockNumber.
+func (s *Session) AtBlock(blockNumber *big.Int) *Session {
+	child := *s
+	child.opts.BlockNumber = blockNumber
+	return &child
+}
+
+// From returns a child session sending calls from the given address.
+func (s *Session) From(from common.Address) *Session {
+	child := *s
+	child.opts.From = &from
+	return &child
+}
+
+// WithStateOverride returns a child session with overrides layered on top of
+// the ones already bound to s.
+func (s *Session) WithStateOverride(overrides StateOverride) *Session {
+	child := *s
+	child.opts.StateOverrides = s.opts.StateOverrides.Merge(overrides)
+	return &child
+}
+
+// WithContext returns a child session issuing its RPCs with ctx.
+func (s *Session) WithContext(ctx context.Context) *Session {
+	child := *s
+	child.opts.Context = ctx
+	return &child
+}
+
+// callOpts layers the per-call options on top of the session ones.
+func (s *Session) callOpts(opts []CallOpts) CallOpts {
+	resolved := s.opts
+	for _, o := range opts {
+		if o.Context != nil {
+			resolved.Context = o.Context
+		}
+		if o.From != nil {
+			resolved.From = o.From
+		}
+		if o.BlockNumber != nil {
+			resolved.BlockNumber = o.BlockNumber
+		}
+		resolved.StateOverrides = resolved.StateOverrides.Merge(o.StateOverrides)
+		resolved.IsCall = resolved.IsCall || o.IsCall
+	}
+
+	return resolved
+}
+
+func (s *Session) AggregateCalls(calls []Call, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.AggregateCallsContext(o.Context, calls, s.backend, o.From, o.BlockNumber, o.IsCall, o.StateOverrides)
+}
+
+func (s *Session) TryAggregateCalls(calls []Call, requireSuccess bool, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.TryAggregateCallsContext(
+		o.Context, calls, requireSuccess, s.backend, o.From, o.BlockNumber, o.IsCall, o.StateOverrides,
+	)
+}
+
+func (s *Session) TryAggregateCalls3(calls []CallWithFailure, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.TryAggregateCalls3Context(o.Context, calls, s.backend, o.From, o.BlockNumber, o.IsCall, o.StateOverrides)
+}
+
+func (s *Session) SimulateCall(calls []Call, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.SimulateCallContext(o.Context, calls, s.backend, o.From, o.BlockNumber, o.StateOverrides)
+}
+
+func (s *Session) SimulateDelegateCall(calls []Call, account common.Address, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.SimulateDelegateCallContext(
+		o.Context, calls, account, s.backend, o.From, o.BlockNumber, o.StateOverrides,
+	)
+}
+
+func (s *Session) AggregateStatic(calls []Call, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.AggregateStaticContext(o.Context, calls, s.backend, o.From, o.BlockNumber, o.StateOverrides)
+}
+
+func (s *Session) TryAggregateStatic(calls []Call, requireSuccess bool, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.TryAggregateStaticContext(
+		o.Context, calls, requireSuccess, s.backend, o.From, o.BlockNumber, o.StateOverrides,
+	)
+}
+
+func (s *Session) BlockAndAggregateStatic(calls []Call, requireSuccess bool, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.BlockAndAggregateStaticContext(
+		o.Context, calls, requireSuccess, s.backend, o.From, o.BlockNumber, o.StateOverrides,
+	)
+}
+
+func (s *Session) TryAggregateStatic3(calls []CallWithFailure, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.TryAggregateStatic3Context(o.Context, calls, s.backend, o.From, o.BlockNumber, o.StateOverrides)
+}
+
+func (s *Session) CodeLengths(addresses []*common.Address, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.CodeLengthsContext(o.Context, addresses, s.backend, o.BlockNumber)
+}
+
+func (s *Session) Balances(addresses []*common.Address, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.BalancesContext(o.Context, addresses, s.backend, o.BlockNumber)
+}
+
+func (s *Session) AddressesData(addresses []*common.Address, opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.AddressesDataContext(o.Context, addresses, s.backend, o.BlockNumber)
+}
+
+func (s *Session) ChainData(opts ...CallOpts) Result {
+	o := s.callOpts(opts)
+	return s.multicall.ChainDataContext(o.Context, s.backend, o.BlockNumber)
+}
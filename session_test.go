@@ -0,0 +1,55 @@
+package multicall
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestSession_ChildSessions(t *testing.T) {
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	addr1 := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	addr2 := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	root := (&MultiCall{}).Session(&fakeBackend{})
+	child := root.AtBlock(big.NewInt(10)).From(from).WithStateOverride(StateOverride{addr1: {}})
+	grandchild := child.WithStateOverride(StateOverride{addr2: {}})
+
+	if root.opts.BlockNumber != nil || root.opts.From != nil || root.opts.StateOverrides != nil {
+		t.Fatalf("root session mutated: %+v", root.opts)
+	}
+	if child.opts.BlockNumber.Int64() != 10 || *child.opts.From != from {
+		t.Fatalf("child opts = %+v", child.opts)
+	}
+	if len(child.opts.StateOverrides) != 1 || len(grandchild.opts.StateOverrides) != 2 {
+		t.Fatalf("overrides not layered: child %v, grandchild %v", child.opts.StateOverrides, grandchild.opts.StateOverrides)
+	}
+
+	o := grandchild.callOpts([]CallOpts{{BlockNumber: big.NewInt(11), IsCall: true}})
+	if o.BlockNumber.Int64() != 11 || *o.From != from || !o.IsCall || len(o.StateOverrides) != 2 {
+		t.Fatalf("per-call opts not applied: %+v", o)
+	}
+}
+
+func TestSession_AtBlock(t *testing.T) {
+	var gotBlock string
+	backend := &fakeBackend{
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			gotBlock = args[1].(string)
+			*result.(*hexutil.Bytes) = make([]byte, 9*32)
+			return nil
+		},
+	}
+
+	m := &MultiCall{ContractAddress: &OMNES_MULTICALL_ADDRESS}
+	res := m.Session(backend).AtBlock(big.NewInt(16)).ChainData()
+	if !res.Success {
+		t.Fatalf("ChainData: %v", res.Error)
+	}
+	if gotBlock != "0x10" {
+		t.Fatalf("block identifier = %s, want 0x10", gotBlock)
+	}
+}
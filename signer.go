@@ -41,7 +41,7 @@ func NewSigner(privateKeyHex string) (SignerInterface, error) {
 }
 
 func (s *GenericSigner) SignTx(tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainId), s.PrivateKey)
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(chainId), s.PrivateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +49,18 @@ func (s *GenericSigner) SignTx(tx *types.Transaction, chainId *big.Int) (*types.
 	return signedTx, nil
 }
 
+// SignHash signs hash with the private key, with V being 27 or 28 so the
+// signature can be checked with ecrecover.
+func (s *GenericSigner) SignHash(hash common.Hash) ([]byte, error) {
+	signature, err := crypto.Sign(hash.Bytes(), s.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
 func (s *GenericSigner) GetAddress() *common.Address {
 	return s.Address
 }
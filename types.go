@@ -25,6 +25,11 @@ type TxOrCall struct {
 
 	AccessList types.AccessList
 	Overrides  StateOverride
+
+	// Hashes are the hashes of every transaction a write broadcast, the first
+	// one followed by its replacements, the last one being the mined one when
+	// a replacement was. It is nil for calls.
+	Hashes []common.Hash
 }
 
 func (t *TxOrCall) String() string {
@@ -55,7 +60,7 @@ func (t *TxOrCall) String() string {
 }
 
 func FromTxToTxOrCall(tx *types.Transaction, from common.Address, blockNumber *big.Int, overrides StateOverride) TxOrCall {
-	return TxOrCall{
+	txOrCall := TxOrCall{
 		From:        from,
 		To:          tx.To(),
 		Gas:         tx.Gas(),
@@ -67,6 +72,12 @@ func FromTxToTxOrCall(tx *types.Transaction, from common.Address, blockNumber *b
 		BlockNumber: blockNumber,
 		Overrides:   overrides,
 	}
+	if tx.Type() != types.LegacyTxType {
+		txOrCall.GasFeeCap = tx.GasFeeCap()
+		txOrCall.GasTipCap = tx.GasTipCap()
+	}
+
+	return txOrCall
 }
 
 func FromCallToTxOrCall(call *ethereum.CallMsg, blockNumber *big.Int, overrides StateOverride) TxOrCall {
@@ -87,6 +98,108 @@ type Result struct {
 	Result   any
 	Error    error
 	TxOrCall TxOrCall
+	// Block is the block the calls ran in, as reported by the contract itself
+	// during the same execution. It is nil for methods whose contract does not report it.
+	Block *BlockStamp
+}
+
+// BlockStamp identifies the block an aggregated call was executed in.
+type BlockStamp struct {
+	Number *big.Int
+	// ParentHash is blockhash(Number - 1), a block's own hash not being visible
+	// during its execution. Through the Omnes contract it is read with a state
+	// override, and left zero on chains that don't support them.
+	ParentHash common.Hash
+	Timestamp  uint64
+	// BaseFee is not reported by Multicall2.
+	BaseFee *big.Int
+}
+
+// CallResult is the outcome of a single call of an aggregated call, shaped the
+// same whatever the method and whether the deployed or deployless path served it.
+type CallResult struct {
+	Index  int
+	Target common.Address
+	// Label is the label the call was given with CallBuilder.Label, if any.
+	Label   string
+	Success bool
+	// ReturnData holds the raw return data, or the revert data when the call failed.
+	ReturnData []byte
+	// Decoded holds ReturnData decoded with the call's ReturnTypes, if any were set.
+	Decoded []any
+	// GasUsed is only set by simulations.
+	GasUsed *big.Int
+	// Revert is the decoded revert of a failed call.
+	Revert *RevertError
+}
+
+func (c CallResult) String() string {
+	description := fmt.Sprintf("{Index: %d", c.Index)
+	if c.Label != "" {
+		description += fmt.Sprintf(", Label: %s", c.Label)
+	}
+	description += fmt.Sprintf(", Success: %t", c.Success)
+	if c.Decoded != nil {
+		description += fmt.Sprintf(", Decoded: %v", c.Decoded)
+	} else {
+		description += fmt.Sprintf(", ReturnData: %s", hexutil.Encode(c.ReturnData))
+	}
+	if c.GasUsed != nil {
+		description += fmt.Sprintf(", GasUsed: %s", c.GasUsed)
+	}
+	if c.Revert != nil {
+		description += fmt.Sprintf(", Revert: %s", c.Revert)
+	}
+
+	return description + "}"
+}
+
+// newCallResults builds the per-call results from the entries returned by the
+// multicall contract: bytes for aggregate methods, (bool,bytes) for try methods
+// and (bool,bytes,uint256) for simulations. Return data may come hex encoded.
+func newCallResults(entries []any, calls CallsInterface) ([]CallResult, error) {
+	callResults := make([]CallResult, len(entries))
+	for i, entry := range entries {
+		callResult := CallResult{Index: i, Success: true}
+
+		switch e := entry.(type) {
+		case []byte:
+			callResult.ReturnData = e
+		case []any:
+			if len(e) < 2 {
+				return nil, fmt.Errorf("call %d: unexpected result entry %v", i, e)
+			}
+			callResult.Success, _ = e[0].(bool)
+			callResult.ReturnData = toBytes(e[1])
+			if len(e) > 2 {
+				callResult.GasUsed, _ = e[2].(*big.Int)
+			}
+		default:
+			return nil, fmt.Errorf("call %d: unexpected result entry %T", i, entry)
+		}
+
+		if i < calls.Len() {
+			callResult.Target = *calls.GetTarget(i)
+			callResult.Label = calls.GetLabel(i)
+
+			returnTypes := calls.GetReturnTypes(i)
+			if callResult.Success && len(returnTypes) > 0 {
+				decoded, err := safeDecode(returnTypes, callResult.ReturnData)
+				if err != nil {
+					return nil, fmt.Errorf("call %d: error decoding %v: %w", i, returnTypes, err)
+				}
+				callResult.Decoded = decoded
+			}
+		}
+
+		if !callResult.Success {
+			callResult.Revert = newRevertError(callResult.ReturnData)
+		}
+
+		callResults[i] = callResult
+	}
+
+	return callResults, nil
 }
 
 func (r *Result) Description(full bool) string {
@@ -107,6 +220,7 @@ type commonCall struct {
 	Args          []interface{}
 	ReturnTypes   []string
 	CallData      []byte
+	Label         string
 }
 
 type Call struct {
@@ -132,6 +246,7 @@ type CallsInterface interface {
 	GetArgs(i int) []interface{}
 	GetCallData(i int) []byte
 	GetReturnTypes(i int) []string
+	GetLabel(i int) string
 	GetValue(i int) *big.Int
 	Len() int
 	ToArray(withValue bool, isMultiCall3Type bool) ([]any, *big.Int, error)
@@ -304,6 +419,10 @@ func (c Calls) GetReturnTypes(i int) []string {
 	return c[i].ReturnTypes
 }
 
+func (c Calls) GetLabel(i int) string {
+	return c[i].Label
+}
+
 func (c Calls) GetValue(i int) *big.Int {
 	return c[i].Value
 }
@@ -369,6 +488,10 @@ func (c CallsWithFailure) GetReturnTypes(i int) []string {
 	return c[i].ReturnTypes
 }
 
+func (c CallsWithFailure) GetLabel(i int) string {
+	return c[i].Label
+}
+
 func (c CallsWithFailure) GetValue(i int) *big.Int {
 	return c[i].Value
 }
@@ -377,6 +500,22 @@ func (c CallsWithFailure) GetRequireSuccess(i int) bool {
 	return c[i].RequireSuccess
 }
 
+func withRequireSuccess(calls []Call, requireSuccess bool) CallsWithFailure {
+	withFailure := make(CallsWithFailure, len(calls))
+	for i, call := range calls {
+		withFailure[i] = CallWithFailure{Call: call, RequireSuccess: requireSuccess}
+	}
+	return withFailure
+}
+
+func (c CallsWithFailure) toCalls() Calls {
+	calls := make(Calls, len(c))
+	for i := range c {
+		calls[i] = c[i].Call
+	}
+	return calls
+}
+
 func (c CallsWithFailure) Len() int {
 	return len(c)
 }
@@ -405,7 +544,7 @@ func (c CallsWithFailure) ToArray(withValue bool, isMultiCall3Type bool) ([]any,
 
 		if isMultiCall3Type {
 			args = append(args, c.GetTarget(i))
-			args = append(args, c.GetRequireSuccess(i))
+			args = append(args, !c.GetRequireSuccess(i)) // allowFailure
 			if withValue {
 				value := big.NewInt(0)
 				if c.GetValue(i) != nil {
@@ -495,12 +634,53 @@ func (s *StateOverride) Add(other StateOverride) {
 	}
 }
 
+// Merge returns a copy of s with the accounts of other layered on top. Unlike Add,
+// an account present in both is taken from other as a whole instead of being summed.
+func (s StateOverride) Merge(other StateOverride) StateOverride {
+	if len(other) == 0 {
+		return s
+	}
+	if len(s) == 0 {
+		return other
+	}
+
+	merged := make(StateOverride, len(s)+len(other))
+	for address, override := range s {
+		merged[address] = override
+	}
+	for address, override := range other {
+		merged[address] = override
+	}
+
+	return merged
+}
+
 type Overrides struct {
 	From           *common.Address
 	StateOverrides StateOverride
 	BlockNumber    *big.Int
 }
 
+// resolve fills the unset call settings from o. Per-call overrides take
+// precedence over o.StateOverrides for the accounts they touch.
+func (o Overrides) resolve(
+	from *common.Address, blockNumber *big.Int, overrides StateOverride,
+) (*common.Address, *big.Int, StateOverride) {
+	if from == nil {
+		from = o.From
+	}
+
+	return from, o.resolveBlockNumber(blockNumber), o.StateOverrides.Merge(overrides)
+}
+
+func (o Overrides) resolveBlockNumber(blockNumber *big.Int) *big.Int {
+	if blockNumber == nil {
+		return o.BlockNumber
+	}
+
+	return blockNumber
+}
+
 // CallMsg-equivalent as a raw map that handles JSON-marshaled RPC data
 type CallArgs struct {
 	From  common.Address  `json:"from,omitempty"`
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/omnes-tech/abi"
 )
 
 func TestOverrideAccount_Add_Balance(t *testing.T) {
@@ -410,3 +411,96 @@ func balanceHex(v int64) *hexutil.Big {
 	b := hexutil.Big(*big.NewInt(v))
 	return &b
 }
+
+func TestStateOverride_Merge(t *testing.T) {
+	addr1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	addr2 := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	t.Run("other replaces shared accounts", func(t *testing.T) {
+		s := StateOverride{addr1: {Balance: balanceHex(100)}}
+		merged := s.Merge(StateOverride{addr1: {Balance: balanceHex(25)}, addr2: {Balance: balanceHex(5)}})
+
+		if merged[addr1].Balance.ToInt().Int64() != 25 {
+			t.Fatalf("addr1 Balance = %v, want 25", merged[addr1].Balance)
+		}
+		if merged[addr2].Balance.ToInt().Int64() != 5 {
+			t.Fatalf("addr2 Balance = %v, want 5", merged[addr2].Balance)
+		}
+	})
+
+	t.Run("receiver is left untouched", func(t *testing.T) {
+		s := StateOverride{addr1: {Balance: balanceHex(100)}}
+		s.Merge(StateOverride{addr2: {Balance: balanceHex(5)}})
+
+		if len(s) != 1 {
+			t.Fatalf("receiver mutated: %#v", s)
+		}
+	})
+
+	t.Run("nil sides", func(t *testing.T) {
+		var s StateOverride
+		if s.Merge(nil) != nil {
+			t.Fatal("expected nil when both sides are empty")
+		}
+		if len(s.Merge(StateOverride{addr1: {}})) != 1 {
+			t.Fatal("expected other when receiver is empty")
+		}
+	})
+}
+
+func TestNewCallResults(t *testing.T) {
+	target := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	calls := NewCalls(
+		[]common.Address{target, target, target},
+		[]string{"a()", "b()", "c()"},
+		nil,
+		nil,
+		[][]string{{"uint256"}, {"uint256"}, nil},
+		nil,
+	)
+
+	okData, _ := abi.Encode([]string{"uint256"}, big.NewInt(9))
+	revertData, _ := abi.EncodeWithSignature("Error(string)", "nope")
+
+	t.Run("aggregate entries", func(t *testing.T) {
+		got, err := newCallResults([]any{okData, okData, []byte{0x01}}, calls)
+		if err != nil {
+			t.Fatalf("newCallResults: %v", err)
+		}
+		if len(got) != 3 || got[1].Index != 1 || got[1].Target != target || !got[1].Success {
+			t.Fatalf("got %v", got)
+		}
+		if got[0].Decoded[0].(*big.Int).Int64() != 9 {
+			t.Fatalf("Decoded = %v, want [9]", got[0].Decoded)
+		}
+		if got[2].Decoded != nil || !bytes.Equal(got[2].ReturnData, []byte{0x01}) {
+			t.Fatalf("call without return types = %v", got[2])
+		}
+	})
+
+	t.Run("try entries decode reverts", func(t *testing.T) {
+		got, err := newCallResults([]any{[]any{true, okData}, []any{false, revertData}}, calls)
+		if err != nil {
+			t.Fatalf("newCallResults: %v", err)
+		}
+		if got[1].Success || got[1].Decoded != nil || got[1].Revert == nil || got[1].Revert.Reason != "nope" {
+			t.Fatalf("failed call = %v", got[1])
+		}
+	})
+
+	t.Run("simulation entries carry gas and hex data", func(t *testing.T) {
+		got, err := newCallResults([]any{[]any{true, hexutil.Encode(okData), big.NewInt(21000)}}, calls)
+		if err != nil {
+			t.Fatalf("newCallResults: %v", err)
+		}
+		if got[0].GasUsed.Int64() != 21000 || got[0].Decoded[0].(*big.Int).Int64() != 9 {
+			t.Fatalf("simulated call = %v", got[0])
+		}
+	})
+
+	t.Run("malformed return data is an error", func(t *testing.T) {
+		if _, err := newCallResults([]any{[]byte{0x01}}, calls); err == nil {
+			t.Fatal("expected decode error")
+		}
+	})
+}
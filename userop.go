@@ -0,0 +1,440 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/omnes-tech/abi"
+)
+
+// ENTRYPOINT_V07_ADDRESS is the canonical ERC-4337 v0.7 EntryPoint.
+var ENTRYPOINT_V07_ADDRESS = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+
+// USER_OPERATION_POLL_INTERVAL is how often the bundler is asked for a user operation receipt.
+const USER_OPERATION_POLL_INTERVAL = 2 * time.Second
+
+// dummySignature is a well formed ECDSA signature used while estimating gas,
+// before the user operation can be signed.
+var dummySignature = common.FromHex(
+	"0xfffffffffffffffffffffffffffffff0000000000000000000000000000000007aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa1c",
+)
+
+var ErrNoHashSigner = errors.New("signer cannot sign hashes")
+
+// HashSigner is implemented by signers that can sign arbitrary hashes, which
+// user operations need.
+type HashSigner interface {
+	// SignHash returns a 65 bytes [R || S || V] signature of hash, with V being 27 or 28.
+	SignHash(hash common.Hash) ([]byte, error)
+}
+
+// Bundler is the subset of a bundler JSON-RPC client used to submit user
+// operations. An *rpc.Client satisfies it.
+type Bundler interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// UserOperation is an ERC-4337 v0.7 user operation, in its unpacked form.
+type UserOperation struct {
+	Sender               common.Address
+	Nonce                *big.Int
+	Factory              *common.Address
+	FactoryData          []byte
+	CallData             []byte
+	CallGasLimit         *big.Int
+	VerificationGasLimit *big.Int
+	PreVerificationGas   *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+
+	Paymaster                     *common.Address
+	PaymasterVerificationGasLimit *big.Int
+	PaymasterPostOpGasLimit       *big.Int
+	PaymasterData                 []byte
+
+	Signature []byte
+}
+
+// Hash returns the hash signed by the account, as computed by
+// EntryPoint.getUserOpHash.
+func (u *UserOperation) Hash(entryPoint common.Address, chainID *big.Int) common.Hash {
+	var initCode []byte
+	if u.Factory != nil {
+		initCode = append(u.Factory.Bytes(), u.FactoryData...)
+	}
+
+	var paymasterAndData []byte
+	if u.Paymaster != nil {
+		paymasterAndData = append(paymasterAndData, u.Paymaster.Bytes()...)
+		paymasterAndData = append(paymasterAndData, packUint128s(u.PaymasterVerificationGasLimit, u.PaymasterPostOpGasLimit)...)
+		paymasterAndData = append(paymasterAndData, u.PaymasterData...)
+	}
+
+	packed := crypto.Keccak256(
+		common.LeftPadBytes(u.Sender.Bytes(), 32),
+		word(u.Nonce),
+		crypto.Keccak256(initCode),
+		crypto.Keccak256(u.CallData),
+		packUint128s(u.VerificationGasLimit, u.CallGasLimit),
+		word(u.PreVerificationGas),
+		packUint128s(u.MaxPriorityFeePerGas, u.MaxFeePerGas),
+		crypto.Keccak256(paymasterAndData),
+	)
+
+	return crypto.Keccak256Hash(packed, common.LeftPadBytes(entryPoint.Bytes(), 32), word(chainID))
+}
+
+// packUint128s packs hi and lo into a single 32 bytes word, as the EntryPoint does for gas limits and fees.
+func packUint128s(hi, lo *big.Int) []byte {
+	packed := make([]byte, 32)
+	if hi != nil {
+		copy(packed[:16], common.LeftPadBytes(hi.Bytes(), 16))
+	}
+	if lo != nil {
+		copy(packed[16:], common.LeftPadBytes(lo.Bytes(), 16))
+	}
+	return packed
+}
+
+// userOperationArgs is the JSON-RPC form of a v0.7 user operation.
+type userOperationArgs struct {
+	Sender               common.Address  `json:"sender"`
+	Nonce                *hexutil.Big    `json:"nonce"`
+	Factory              *common.Address `json:"factory,omitempty"`
+	FactoryData          hexutil.Bytes   `json:"factoryData,omitempty"`
+	CallData             hexutil.Bytes   `json:"callData"`
+	CallGasLimit         *hexutil.Big    `json:"callGasLimit"`
+	VerificationGasLimit *hexutil.Big    `json:"verificationGasLimit"`
+	PreVerificationGas   *hexutil.Big    `json:"preVerificationGas"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+
+	Paymaster                     *common.Address `json:"paymaster,omitempty"`
+	PaymasterVerificationGasLimit *hexutil.Big    `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       *hexutil.Big    `json:"paymasterPostOpGasLimit,omitempty"`
+	PaymasterData                 hexutil.Bytes   `json:"paymasterData,omitempty"`
+
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+func (u *UserOperation) args() userOperationArgs {
+	hexBig := func(v *big.Int) *hexutil.Big {
+		if v == nil {
+			return new(hexutil.Big)
+		}
+		return (*hexutil.Big)(v)
+	}
+
+	args := userOperationArgs{
+		Sender:               u.Sender,
+		Nonce:                hexBig(u.Nonce),
+		Factory:              u.Factory,
+		CallData:             u.CallData,
+		CallGasLimit:         hexBig(u.CallGasLimit),
+		VerificationGasLimit: hexBig(u.VerificationGasLimit),
+		PreVerificationGas:   hexBig(u.PreVerificationGas),
+		MaxFeePerGas:         hexBig(u.MaxFeePerGas),
+		MaxPriorityFeePerGas: hexBig(u.MaxPriorityFeePerGas),
+		Signature:            u.Signature,
+	}
+	if u.Factory != nil {
+		args.FactoryData = u.FactoryData
+	}
+	if u.Paymaster != nil {
+		args.Paymaster = u.Paymaster
+		args.PaymasterVerificationGasLimit = hexBig(u.PaymasterVerificationGasLimit)
+		args.PaymasterPostOpGasLimit = hexBig(u.PaymasterPostOpGasLimit)
+		args.PaymasterData = u.PaymasterData
+	}
+
+	return args
+}
+
+// UserOperationReceipt is the receipt returned by eth_getUserOperationReceipt.
+type UserOperationReceipt struct {
+	UserOpHash    common.Hash    `json:"userOpHash"`
+	EntryPoint    common.Address `json:"entryPoint"`
+	Sender        common.Address `json:"sender"`
+	Nonce         *hexutil.Big   `json:"nonce"`
+	Paymaster     common.Address `json:"paymaster"`
+	ActualGasCost *hexutil.Big   `json:"actualGasCost"`
+	ActualGasUsed *hexutil.Big   `json:"actualGasUsed"`
+	Success       bool           `json:"success"`
+	Reason        string         `json:"reason"`
+	Logs          []*types.Log   `json:"logs"`
+	Receipt       *types.Receipt `json:"receipt"`
+	// Revert is the decoded Reason of a failed user operation.
+	Revert *RevertError `json:"-"`
+}
+
+// UserOpOptions configures how calls are turned into a user operation. Zero
+// values are filled in from the chain and the bundler.
+type UserOpOptions struct {
+	// EntryPoint is ENTRYPOINT_V07_ADDRESS when nil.
+	EntryPoint *common.Address
+	// NonceKey selects the EntryPoint nonce sequence, 0 when nil.
+	NonceKey *big.Int
+	// EncodeBatch builds the account callData executing calls, ExecuteBatchCallData when nil.
+	EncodeBatch func(calls Calls) ([]byte, error)
+
+	// Factory and FactoryData deploy the account with its first user operation.
+	Factory     *common.Address
+	FactoryData []byte
+
+	Paymaster     *common.Address
+	PaymasterData []byte
+
+	// MaxFeePerGas is derived with Fees when nil, as is MaxPriorityFeePerGas unless
+	// MaxFeePerGas is set, in which case it defaults to MaxFeePerGas.
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	Fees                 FeeStrategy
+
+	// DummySignature is sent while estimating gas, a generic ECDSA signature when nil.
+	DummySignature []byte
+}
+
+func (o UserOpOptions) entryPoint() common.Address {
+	if o.EntryPoint == nil {
+		return ENTRYPOINT_V07_ADDRESS
+	}
+	return *o.EntryPoint
+}
+
+// ExecuteBatchCallData encodes calls as executeBatch(address[],uint256[],bytes[]),
+// the batch entry point of the reference SimpleAccount and many accounts derived from it.
+func ExecuteBatchCallData(calls Calls) ([]byte, error) {
+	var targets, values, datas []any
+	for i := range calls {
+		callData, err := encodeCallData(calls, i)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding call %d: %w", i, err)
+		}
+
+		value := calls.GetValue(i)
+		if value == nil {
+			value = big.NewInt(0)
+		}
+
+		targets = append(targets, calls.GetTarget(i))
+		values = append(values, value)
+		datas = append(datas, callData)
+	}
+
+	return abi.EncodeWithSignature("executeBatch(address[],uint256[],bytes[])", targets, values, datas)
+}
+
+func BuildUserOperation(
+	client Backend, bundler Bundler, sender common.Address, calls []Call, opts UserOpOptions,
+) (*UserOperation, error) {
+	return BuildUserOperationContext(context.Background(), client, bundler, sender, calls, opts)
+}
+
+// BuildUserOperationContext turns calls into an unsigned user operation for the
+// account sender: the nonce is read from the EntryPoint and gas limits are
+// estimated by the bundler with eth_estimateUserOperationGas.
+func BuildUserOperationContext(
+	ctx context.Context, client Backend, bundler Bundler, sender common.Address, calls []Call, opts UserOpOptions,
+) (*UserOperation, error) {
+	encodeBatch := opts.EncodeBatch
+	if encodeBatch == nil {
+		encodeBatch = ExecuteBatchCallData
+	}
+	callData, err := encodeBatch(calls)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding batch: %w", err)
+	}
+
+	nonce, err := entryPointNonce(ctx, client, opts.entryPoint(), sender, opts.NonceKey)
+	if err != nil {
+		return nil, err
+	}
+
+	maxFee, maxPriorityFee := opts.MaxFeePerGas, opts.MaxPriorityFeePerGas
+	if maxFee == nil {
+		var tipCap *big.Int
+		maxFee, tipCap, err = suggestFees(ctx, client, opts.Fees)
+		if err != nil {
+			return nil, err
+		}
+		if maxPriorityFee == nil {
+			maxPriorityFee = tipCap
+		}
+	}
+	if maxPriorityFee == nil {
+		maxPriorityFee = maxFee
+	}
+
+	userOp := &UserOperation{
+		Sender:               sender,
+		Nonce:                nonce,
+		Factory:              opts.Factory,
+		FactoryData:          opts.FactoryData,
+		CallData:             callData,
+		MaxFeePerGas:         maxFee,
+		MaxPriorityFeePerGas: maxPriorityFee,
+		Paymaster:            opts.Paymaster,
+		PaymasterData:        opts.PaymasterData,
+		Signature:            opts.DummySignature,
+	}
+	if userOp.Signature == nil {
+		userOp.Signature = dummySignature
+	}
+
+	var estimate struct {
+		PreVerificationGas            *hexutil.Big `json:"preVerificationGas"`
+		VerificationGasLimit          *hexutil.Big `json:"verificationGasLimit"`
+		CallGasLimit                  *hexutil.Big `json:"callGasLimit"`
+		PaymasterVerificationGasLimit *hexutil.Big `json:"paymasterVerificationGasLimit"`
+		PaymasterPostOpGasLimit       *hexutil.Big `json:"paymasterPostOpGasLimit"`
+	}
+	err = bundler.CallContext(ctx, &estimate, "eth_estimateUserOperationGas", userOp.args(), opts.entryPoint())
+	if err != nil {
+		if revertData, ok := parseRevertData(err); ok {
+			return nil, fmt.Errorf("error estimating user operation gas: %w: %w", newRevertError(revertData), err)
+		}
+		return nil, fmt.Errorf("error estimating user operation gas: %w", err)
+	}
+	if estimate.PreVerificationGas == nil || estimate.VerificationGasLimit == nil || estimate.CallGasLimit == nil {
+		return nil, fmt.Errorf("incomplete user operation gas estimate")
+	}
+
+	userOp.PreVerificationGas = estimate.PreVerificationGas.ToInt()
+	userOp.VerificationGasLimit = estimate.VerificationGasLimit.ToInt()
+	userOp.CallGasLimit = estimate.CallGasLimit.ToInt()
+	if estimate.PaymasterVerificationGasLimit != nil {
+		userOp.PaymasterVerificationGasLimit = estimate.PaymasterVerificationGasLimit.ToInt()
+	}
+	if estimate.PaymasterPostOpGasLimit != nil {
+		userOp.PaymasterPostOpGasLimit = estimate.PaymasterPostOpGasLimit.ToInt()
+	}
+	userOp.Signature = nil
+
+	return userOp, nil
+}
+
+func entryPointNonce(
+	ctx context.Context, client Backend, entryPoint, sender common.Address, key *big.Int,
+) (*big.Int, error) {
+	if key == nil {
+		key = big.NewInt(0)
+	}
+
+	callData, err := abi.EncodeWithSignature("getNonce(address,uint192)", &sender, key)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, _, err := readContract(ctx, client, nil, &entryPoint, nil, callData, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting nonce: %w", err)
+	}
+
+	decoded, err := safeDecode([]string{"uint256"}, encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding nonce: %w", err)
+	}
+
+	return decoded[0].(*big.Int), nil
+}
+
+// SignUserOperation signs userOp with the EIP-191 personal message scheme used by
+// SimpleAccount and most ECDSA accounts. signer must implement HashSigner.
+func SignUserOperation(
+	userOp *UserOperation, signer SignerInterface, entryPoint common.Address, chainID *big.Int,
+) error {
+	hashSigner, ok := signer.(HashSigner)
+	if !ok {
+		return ErrNoHashSigner
+	}
+
+	hash := userOp.Hash(entryPoint, chainID)
+	signature, err := hashSigner.SignHash(common.BytesToHash(accounts.TextHash(hash.Bytes())))
+	if err != nil {
+		return fmt.Errorf("error signing user operation: %w", err)
+	}
+
+	userOp.Signature = signature
+	return nil
+}
+
+func SendUserOperation(
+	client Backend, bundler Bundler, signer SignerInterface, sender common.Address, calls []Call, opts UserOpOptions,
+) (common.Hash, *UserOperationReceipt, error) {
+	return SendUserOperationContext(context.Background(), client, bundler, signer, sender, calls, opts)
+}
+
+// SendUserOperationContext builds a user operation executing calls from the
+// account sender, signs it, submits it with eth_sendUserOperation and waits
+// for its receipt. The user operation hash is returned even when waiting fails.
+func SendUserOperationContext(
+	ctx context.Context, client Backend, bundler Bundler, signer SignerInterface, sender common.Address, calls []Call, opts UserOpOptions,
+) (common.Hash, *UserOperationReceipt, error) {
+	if signer == nil {
+		return common.Hash{}, nil, ErrNoSigner
+	}
+
+	userOp, err := BuildUserOperationContext(ctx, client, bundler, sender, calls, opts)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return common.Hash{}, nil, fmt.Errorf("error getting chain id: %w", err)
+	}
+
+	if err := SignUserOperation(userOp, signer, opts.entryPoint(), chainID); err != nil {
+		return common.Hash{}, nil, err
+	}
+
+	var userOpHash common.Hash
+	if err := bundler.CallContext(ctx, &userOpHash, "eth_sendUserOperation", userOp.args(), opts.entryPoint()); err != nil {
+		return common.Hash{}, nil, fmt.Errorf("error sending user operation: %w", err)
+	}
+
+	receipt, err := WaitUserOperationReceipt(ctx, bundler, userOpHash)
+	if err != nil {
+		return userOpHash, nil, err
+	}
+
+	return userOpHash, receipt, nil
+}
+
+// WaitUserOperationReceipt polls the bundler until userOpHash is included, for
+// at most MINING_WAIT_DURATION.
+func WaitUserOperationReceipt(ctx context.Context, bundler Bundler, userOpHash common.Hash) (*UserOperationReceipt, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, MINING_WAIT_DURATION)
+	defer cancel()
+
+	ticker := time.NewTicker(USER_OPERATION_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		var receipt *UserOperationReceipt
+		err := bundler.CallContext(waitCtx, &receipt, "eth_getUserOperationReceipt", userOpHash)
+		if err != nil {
+			return nil, fmt.Errorf("error getting user operation receipt (userOpHash=%v): %w", userOpHash, err)
+		}
+		if receipt != nil {
+			if !receipt.Success {
+				receipt.Revert = newRevertError(common.FromHex(receipt.Reason))
+			}
+			return receipt, nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			return nil, fmt.Errorf("error while waiting for receipt (userOpHash=%v): %w", userOpHash, waitCtx.Err())
+		case <-ticker.C:
+		}
+	}
+}
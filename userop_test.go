@@ -0,0 +1,162 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/omnes-tech/abi"
+)
+
+// fakeBundler answers bundler JSON-RPC methods with handle.
+type fakeBundler func(method string, args ...interface{}) (any, error)
+
+func (f fakeBundler) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	response, err := f(method, args...)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, result)
+}
+
+func TestUserOperationHash(t *testing.T) {
+	factory := common.HexToAddress("0x00000000000000000000000000000000000fac70")
+	paymaster := common.HexToAddress("0x0000000000000000000000000000000000000ba5")
+	userOp := &UserOperation{
+		Sender:                        common.HexToAddress("0x000000000000000000000000000000000000a11c"),
+		Nonce:                         big.NewInt(3),
+		Factory:                       &factory,
+		FactoryData:                   []byte{0x01, 0x02},
+		CallData:                      []byte{0xde, 0xad},
+		CallGasLimit:                  big.NewInt(100000),
+		VerificationGasLimit:          big.NewInt(200000),
+		PreVerificationGas:            big.NewInt(50000),
+		MaxFeePerGas:                  big.NewInt(30),
+		MaxPriorityFeePerGas:          big.NewInt(2),
+		Paymaster:                     &paymaster,
+		PaymasterVerificationGasLimit: big.NewInt(40000),
+		PaymasterPostOpGasLimit:       big.NewInt(10000),
+		PaymasterData:                 []byte{0x03},
+	}
+
+	// pack the user operation like EntryPoint v0.7 UserOperationLib does
+	bytes32, _ := gethabi.NewType("bytes32", "", nil)
+	uint256, _ := gethabi.NewType("uint256", "", nil)
+	address, _ := gethabi.NewType("address", "", nil)
+	packed, err := gethabi.Arguments{
+		{Type: address}, {Type: uint256}, {Type: bytes32}, {Type: bytes32},
+		{Type: bytes32}, {Type: uint256}, {Type: bytes32}, {Type: bytes32},
+	}.Pack(
+		userOp.Sender,
+		userOp.Nonce,
+		crypto.Keccak256Hash(append(factory.Bytes(), userOp.FactoryData...)),
+		crypto.Keccak256Hash(userOp.CallData),
+		common.BytesToHash(append(common.LeftPadBytes(big.NewInt(200000).Bytes(), 16), common.LeftPadBytes(big.NewInt(100000).Bytes(), 16)...)),
+		userOp.PreVerificationGas,
+		common.BytesToHash(append(common.LeftPadBytes([]byte{2}, 16), common.LeftPadBytes([]byte{30}, 16)...)),
+		crypto.Keccak256Hash(paymaster.Bytes(), common.LeftPadBytes(big.NewInt(40000).Bytes(), 16), common.LeftPadBytes(big.NewInt(10000).Bytes(), 16), []byte{0x03}),
+	)
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	want, _ := gethabi.Arguments{{Type: bytes32}, {Type: address}, {Type: uint256}}.Pack(
+		crypto.Keccak256Hash(packed), ENTRYPOINT_V07_ADDRESS, big.NewInt(1),
+	)
+
+	if got := userOp.Hash(ENTRYPOINT_V07_ADDRESS, big.NewInt(1)); got != crypto.Keccak256Hash(want) {
+		t.Fatalf("Hash = %s, want %s", got, crypto.Keccak256Hash(want))
+	}
+}
+
+func TestSendUserOperation(t *testing.T) {
+	signer, err := NewSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	account := common.HexToAddress("0x000000000000000000000000000000000000a11c")
+	target := common.HexToAddress("0x000000000000000000000000000000000000beef")
+
+	backend := &fakeBackend{
+		callContext: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			call := args[0].(CallArgs)
+			if *call.To != ENTRYPOINT_V07_ADDRESS || !bytes.HasPrefix(call.Data, abi.EncodeSignature("getNonce(address,uint192)")) {
+				t.Fatalf("unexpected call to %s", call.To)
+			}
+			nonce, _ := abi.Encode([]string{"uint256"}, big.NewInt(5))
+			*result.(*hexutil.Bytes) = nonce
+			return nil
+		},
+	}
+
+	userOpHash := common.HexToHash("0x0123")
+	var sent userOperationArgs
+	bundler := fakeBundler(func(method string, args ...interface{}) (any, error) {
+		switch method {
+		case "eth_estimateUserOperationGas":
+			if len(args[0].(userOperationArgs).Signature) == 0 {
+				t.Fatalf("estimated without a dummy signature")
+			}
+			return map[string]string{
+				"preVerificationGas":   "0xc350",
+				"verificationGasLimit": "0x30d40",
+				"callGasLimit":         "0x186a0",
+			}, nil
+		case "eth_sendUserOperation":
+			sent = args[0].(userOperationArgs)
+			return userOpHash, nil
+		case "eth_getUserOperationReceipt":
+			return map[string]any{"userOpHash": userOpHash, "sender": account, "success": true}, nil
+		}
+		t.Fatalf("unexpected method %s", method)
+		return nil, nil
+	})
+
+	calls := NewCalls(
+		[]common.Address{target, target}, []string{"a()", "b(uint256)"}, [][]any{nil, {big.NewInt(1)}}, nil, nil,
+		[]*big.Int{nil, big.NewInt(7)},
+	)
+	hash, receipt, err := SendUserOperation(backend, bundler, signer, account, calls, UserOpOptions{MaxFeePerGas: big.NewInt(30)})
+	if err != nil {
+		t.Fatalf("SendUserOperation: %v", err)
+	}
+	if hash != userOpHash || receipt == nil || !receipt.Success || receipt.Sender != account {
+		t.Fatalf("hash = %s, receipt = %+v", hash, receipt)
+	}
+
+	wantCallData, _ := ExecuteBatchCallData(calls)
+	if !bytes.Equal(sent.CallData, wantCallData) {
+		t.Fatalf("CallData = %x, want executeBatch", sent.CallData)
+	}
+	if sent.Nonce.ToInt().Int64() != 5 || sent.CallGasLimit.ToInt().Int64() != 100000 {
+		t.Fatalf("Nonce = %v, CallGasLimit = %v", sent.Nonce, sent.CallGasLimit)
+	}
+
+	userOp := &UserOperation{
+		Sender:               sent.Sender,
+		Nonce:                sent.Nonce.ToInt(),
+		CallData:             sent.CallData,
+		CallGasLimit:         sent.CallGasLimit.ToInt(),
+		VerificationGasLimit: sent.VerificationGasLimit.ToInt(),
+		PreVerificationGas:   sent.PreVerificationGas.ToInt(),
+		MaxFeePerGas:         sent.MaxFeePerGas.ToInt(),
+		MaxPriorityFeePerGas: sent.MaxPriorityFeePerGas.ToInt(),
+	}
+	digest := accounts.TextHash(userOp.Hash(ENTRYPOINT_V07_ADDRESS, big.NewInt(1)).Bytes())
+	signature := append([]byte{}, sent.Signature...)
+	signature[crypto.RecoveryIDOffset] -= 27
+	publicKey, err := crypto.SigToPub(digest, signature)
+	if err != nil || crypto.PubkeyToAddress(*publicKey) != *signer.GetAddress() {
+		t.Fatalf("signature does not recover to the signer: %v", err)
+	}
+}
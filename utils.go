@@ -1,10 +1,12 @@
 package multicall
 
 import (
+	"fmt"
 	"math/big"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/omnes-tech/abi"
 )
 
 // Add0xPrefix adds 0x hex prefix to a string, if needed.
@@ -35,3 +37,29 @@ func mergeStorageMaps(dst, src map[common.Hash]common.Hash) map[common.Hash]comm
 	}
 	return dst
 }
+
+// safeDecode is abi.Decode guarded against the panics it raises on truncated data.
+func safeDecode(typeStrs []string, data []byte) (values []any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			values, err = nil, fmt.Errorf("malformed ABI data for %v: %v", typeStrs, r)
+		}
+	}()
+
+	return abi.Decode(typeStrs, data)
+}
+
+// word left pads v to a 32 bytes ABI word, nil being zero.
+func word(v *big.Int) []byte {
+	if v == nil {
+		return make([]byte, 32)
+	}
+	return common.LeftPadBytes(v.Bytes(), 32)
+}
+
+func orZero(v *big.Int) *big.Int {
+	if v == nil {
+		return big.NewInt(0)
+	}
+	return v
+}